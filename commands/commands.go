@@ -7,34 +7,154 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/mattbro2/filesequence/config"
 	"github.com/mattbro2/filesequence/filesys"
 )
 
 type Options struct {
-	Curdir  string
-	Reverse string
-	Copy    string
-	Move    string
-	Delete  string
-	Reseq   string
-	Nocolor bool
-	Force   bool
-	Verbose bool
+	Curdir         string
+	Reverse        string
+	Copy           string
+	Move           string
+	Delete         string
+	Reseq          string
+	Batch          string
+	BatchFile      string
+	Op             string
+	Dest           string
+	Exclude        string
+	Frames         string
+	Match          string
+	Offset         string
+	Start          string
+	Pad            int
+	Map            string
+	Fill           string
+	Range          string
+	Fps            string
+	TcStart        string
+	TcFrame        int
+	Link           string
+	Preserve       string
+	BwLimit        string
+	FileLimit      float64
+	ThrottleFile   string
+	Workers        int
+	FillFrom       string
+	Sidecar        bool
+	Preview        bool
+	Progress       bool
+	Undo           string
+	History        bool
+	UndoDir        string
+	Trash          bool
+	TrashLs        bool
+	Restore        string
+	Purge          string
+	Manifest       string
+	ManifestFile   string
+	ManifestFormat string
+	Verify         string
+	Diff           string
+	Sync           string
+	Health         string
+	HealthRatio    float64
+	HealthWindow   int
+	Info           string
+	Ffmpeg         string
+	Ffconcat       string
+	Edl            string
+	Otio           string
+	Missing        string
+	ReadOtio       string
+	Format         string
+	Watch          string
+	Poll           time.Duration
+	Prune          bool
+	Checksum       bool
+	Json           bool
+	Nocolor        bool
+	Force          bool
+	Yes            bool
+	Verbose        bool
 }
 
 //InitCommands parses command line flags
 //updates global options
 func InitCommands(out io.Writer) Options {
 	printUsage := false
+	cfg, cfg_err := config.LoadConfig()
+	if cfg_err != nil {
+		//A bad config should not stop commands that do not use it, ie: a listing
+		fmt.Fprintf(os.Stderr, "Unable to load config %s - %v, using the default settings\n", config.ConfigPath(), cfg_err)
+		cfg = config.DefaultConfig()
+	}
 	curdir := filesys.Curdir()
 	reverse := ""
 	copyf := ""
 	move := ""
 	deletef := ""
 	reseq := ""
+	batch := ""
+	batch_file := ""
+	op := ""
+	dest := ""
+	exclude := ""
+	frames := ""
+	match := ""
+	offset := ""
+	start := ""
+	pad := -1
+	mapf := ""
+	fill := ""
+	frame_range := ""
+	fps := cfg.Fps
+	tc_start := ""
+	tc_frame := -1
+	link := "copy"
+	preserve := ""
+	bwlimit := cfg.BytesPerSec
+	file_limit := cfg.FilesPerSec
+	throttle_file := cfg.ThrottleFile
+	workers := cfg.Workers
+	fill_from := "nearest"
+	sidecar := false
+	preview := false
+	show_progress := false
+	undof := ""
+	history := false
+	undo_dir := cfg.UndoDir
+	use_trash := cfg.Trash
+	trash_list := false
+	restore := ""
+	purge := ""
+	manifestf := ""
+	manifest_file := ""
+	manifest_format := "sha256"
+	verify := ""
+	diff := ""
+	sync := ""
+	health := ""
+	health_ratio := cfg.HealthRatio
+	health_window := cfg.HealthWindow
+	info := ""
+	ffmpeg := ""
+	ffconcat := ""
+	edl := ""
+	otio := ""
+	missing := "error"
+	read_otio := ""
+	format := "native"
+	watchf := ""
+	poll := time.Duration(0)
+	prune := false
+	checksum := false
+	print_json := false
 	nocolor := false
 	force := false
+	yes := false
 	verbose := false
 
 	flagset := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
@@ -43,13 +163,99 @@ func InitCommands(out io.Writer) Options {
 	flagset.BoolVar(&printUsage, "help", false, "Print Help")
 	flagset.StringVar(&curdir, "p", curdir, "Set directory to search")
 	flagset.StringVar(&reverse, "r", "", "Take a F_seq and expand to list of files (offline files are printed to terminal in red)")
-	flagset.StringVar(&copyf, "c", copyf, "Copy ie: fseq1.[01-10].jpg::fseq2.[01-10].jpg - cannot be a resequencing of same files")
+	flagset.StringVar(&copyf, "c", copyf, "Copy ie: fseq1.[01-10].jpg::fseq2.[01-10].jpg - cannot be a resequencing of same files\n\t"+
+		"the destination may be a directory or a template ie: fseq1.[01-10].jpg::/archive/{name}_v2.{frame:04d}.{ext}\n\t"+
+		"template fields are dir, name, sep, frame, ext, padding and the groups of -match")
 	flagset.StringVar(&move, "m", move, "Move ie: fseq1.[01-10].jpg::fseq2.[01-10].jpg\n\t"+
-		"Move will result in original files being renamed. Source and dest must be different")
-	flagset.StringVar(&reseq, "q", reseq, "Renumber a sequence of files ie: fseq1.[001-009].jpg::fseq1.[101-109].jpg")
-	flagset.StringVar(&deletef, "d", deletef, "Remove all files in sequence")
+		"Move will result in original files being renamed. Source and dest must be different\n\t"+
+		"the destination may be a directory or a template like -c")
+	flagset.StringVar(&match, "match", match, "Regex matched against the source path, its groups may be used in a destination template\n\t"+
+		"with -batch only the sequences matching it are used\n\t"+
+		"ie: -match '/shots/(?P<shot>[^/]+)/' -c ...::/archive/{shot}/{name}.{frame}.{ext}")
+	flagset.StringVar(&reseq, "q", reseq, "Renumber a sequence of files ie: fseq1.[001-009].jpg::fseq1.[101-109].jpg\n\t"+
+		"or give only the source with -offset, -start or -pad ie: -q fseq1.[001-009].jpg -offset +100")
+	flagset.StringVar(&batch, "batch", batch, "Apply -op to every sequence found under this directory ie: -batch /shots -op copy -dest /archive/\n\t"+
+		"sequences may be chosen with -match and -exclude, there is a single confirmation and a summary at the end")
+	flagset.StringVar(&batch_file, "batchfile", batch_file, "Apply -op to every listing in this file, one per line, lines may also be source::dest pairs\n\t"+
+		"or to the image sequence references of an OpenTimelineIO .otio file")
+	flagset.StringVar(&op, "op", op, "Operation of a batch: copy, move, delete or reseq")
+	flagset.StringVar(&dest, "dest", dest, "Destination directory or template of a batch copy, move or reseq ie: /archive/{name}.{frame:04d}.{ext}\n\t"+
+		"a reseq may use -offset, -start or -pad instead")
+	flagset.StringVar(&exclude, "exclude", exclude, "Regex of sequences a batch skips ie: '_v[0-9]+\\.'")
+	flagset.StringVar(&frames, "frames", frames, "Only use these frames of the source for -c, -m, -d or -q ie: 1001-1050 or 1001-1100x2\n\t"+
+		"or timecode with -fps ie: 01:00:10:00-01:00:12:00\n\t"+
+		"the destination may list every source frame, only the chosen frames, or be derived with -offset, -start or -pad")
+	flagset.StringVar(&offset, "offset", offset, "Shift frame numbers of the destination ie: +100 or -50")
+	flagset.StringVar(&start, "start", start, "Number the destination so the first frame is this number, gaps are kept ie: 1001")
+	flagset.IntVar(&pad, "pad", pad, "Pad frame numbers of the destination to this many digits, 0 for no padding\n\t"+
+		"Used with only a source listing for -c, -m or -q ie: -c img.[1-250].jpg -pad 4")
+	flagset.StringVar(&link, "link", link, "How -c and -fill create destination files: copy, hard, sym, relative-sym,\n\t"+
+		"reflink (copy on write clone on btrfs or XFS) or auto (reflink when possible, otherwise copy)")
+	flagset.StringVar(&preserve, "preserve", preserve, "Attributes of the source kept on copies, comma separated: mode, times, owner, xattr or all\n\t"+
+		"ie: -preserve mode,times like 'cp -p', attributes that could not be kept are reported")
+	flagset.StringVar(&bwlimit, "bwlimit", bwlimit, "Limit the bytes per second of -c, -m and -fill copies ie: 50M, 1.5G or 100MiB, unlimited when empty")
+	flagset.Float64Var(&file_limit, "filelimit", file_limit, "Limit the files per second of -c, -m and -fill, 0 is unlimited")
+	flagset.StringVar(&throttle_file, "throttlefile", throttle_file, "Control file checked every second for new limits while a job runs ie:\n\t"+
+		"bytes_per_sec = 50M\n\tfiles_per_sec = 20\n\t"+
+		"the limits may also be halved with SIGUSR1 or doubled with SIGUSR2 ie: kill -USR1 <pid>")
+	flagset.IntVar(&workers, "workers", workers, "Number of files -c copies at the same time, the limits are shared by all of them")
+	flagset.StringVar(&mapf, "map", mapf, "Retime a copy with a frame mapping of source frames, destination frames are numbered from\n\t"+
+		"the first destination frame ie: -c fseq1.[1-10].jpg::fseq2.[1].jpg -map reverse, works with -link\n\t"+
+		"terms are separated by commas: 5 | 1-10 | 10-1 (reversed) | 1-10:2 (every other) | 1x24 (hold) | 1-10x2 (twos) | all | reverse")
+	flagset.BoolVar(&preview, "preview", preview, "Print the source -> destination frames of a -map copy, or the frames a -sync would copy and delete, without changing anything\n\t"+
+		"other operations refuse -preview")
+	flagset.StringVar(&fill, "fill", fill, "Create the missing frames of a sequence from the nearest online frames ie: fseq1.[1001-1100].exr")
+	flagset.StringVar(&frame_range, "range", frame_range, "Frames a -fill should create ie: 1001-1100 or timecode with -fps, defaults to the first through last online frame\n\t"+
+		"or the frames a -watch sequence must have to be complete")
+	flagset.StringVar(&fill_from, "fillfrom", fill_from, "Which online frame -fill uses: prev, next or nearest")
+	flagset.StringVar(&fps, "fps", fps, "Frame rate of timecode in -frames and -range ie: 24, 23.976, 25, 29.97df or 59.94df, listings show their duration when it is set")
+	flagset.StringVar(&tc_start, "tcstart", tc_start, "Timecode of frame -tcframe ie: 01:00:00:00, timecode ranges are offset from it, and listings show the timecode of their frames")
+	flagset.IntVar(&tc_frame, "tcframe", tc_frame, "Frame number at the timecode of -tcstart ie: 1001, defaults to the first frame of each sequence")
+	flagset.BoolVar(&sidecar, "sidecar", sidecar, "Record the frames created by -fill in a <name>placeholders.txt file next to the sequence")
+	flagset.StringVar(&deletef, "d", deletef, "Remove all files in sequence (files are moved to the trash unless -trash=false)")
+	flagset.StringVar(&undof, "undo", undof, "Reverse a move, renumber or delete by id from the undo log, or 'last' for the most recent")
+	flagset.BoolVar(&history, "history", history, "List the operations in the undo log")
+	flagset.StringVar(&undo_dir, "undodir", undo_dir, "Set directory of the undo log")
+	flagset.BoolVar(&use_trash, "trash", use_trash, "Move deleted files to the trash, -trash=false removes them permanently")
+	flagset.BoolVar(&trash_list, "trashlist", trash_list, "List the sequences in the trash")
+	flagset.StringVar(&restore, "restore", restore, "Restore a sequence from the trash ie: fseq1.[01-10].jpg")
+	flagset.StringVar(&purge, "purge", purge, "Permanently remove a sequence from the trash, or 'all' to empty the trash")
+	flagset.BoolVar(&show_progress, "progress", show_progress, "Show the progress of -c, -m, -d and -fill on stderr, files and bytes done, throughput and ETA\n\t"+
+		"a single updating line on a terminal, otherwise a json event per line ie: {\"event\":\"progress\",\"files_done\":45,...}")
+	flagset.StringVar(&manifestf, "manifest", manifestf, "Write a checksum manifest of the frames of a sequence with their size and mtime ie: plate.[1001-1100].exr\n\t"+
+		"written next to the sequence as plate.manifest.sha256 unless -manifestfile is given, it may be checked with 'sha256sum -c', use -f to overwrite an existing manifest")
+	flagset.StringVar(&manifest_file, "manifestfile", manifest_file, "Path of the manifest -manifest writes, a .mhl extension writes an ASC MHL file")
+	flagset.StringVar(&manifest_format, "manifestformat", manifest_format, "Format of -manifest: sha256 or mhl")
+	flagset.StringVar(&verify, "verify", verify, "Check a sequence against a manifest ie: plate.manifest.sha256 or plate.mhl\n\t"+
+		"reports missing, extra, changed and zero-byte frames")
+	flagset.StringVar(&diff, "diff", diff, "Compare two sequences or two directories ie: old/plate.[1001-1100].exr::new/plate.[1001-1100].exr or delivery_v1::delivery_v2\n\t"+
+		"reports sequences added and removed, frames gained and lost, and frames whose size differs")
+	flagset.StringVar(&sync, "sync", sync, "Copy only the frames that are missing or different at the destination ie: plate.[1001-1100].exr::/archive/plate.[1001-1100].exr\n\t"+
+		"or every sequence of a directory tree ie: /shots::/archive/shots, frames are compared by size and mtime, or with -checksum")
+	flagset.BoolVar(&prune, "prune", prune, "Delete frames and sequences at the -sync destination that are not in the source (to the trash unless -trash=false)")
+	flagset.BoolVar(&checksum, "checksum", checksum, "Compare the sha256 checksum of frames with the same size for -diff and -sync")
+	flagset.StringVar(&health, "health", health, "Check the frames of a sequence, or every sequence under a directory, for frames that are missing, 0 bytes, truncated or unreadable\n\t"+
+		"prints the frames to render again of each sequence ie: 1004,1050-1051")
+	flagset.Float64Var(&health_ratio, "healthratio", health_ratio, "A frame smaller than this fraction of the median size of the frames around it is truncated")
+	flagset.IntVar(&health_window, "healthwindow", health_window, "Number of frames on each side of a frame used for the median size of -health")
+	flagset.StringVar(&info, "info", info, "Read the image headers of a sequence ie: plate.[1001-1100].exr, prints the resolution, channels, bit depth, compression,\n\t"+
+		"timecode and frame rate, and the frames whose header is different from most of the frames")
+	flagset.StringVar(&ffmpeg, "ffmpeg", ffmpeg, "Print the ffmpeg input arguments of a sequence at -fps (default 24) ie: -framerate 24 -start_number 1001 -i plate.%04d.exr\n\t"+
+		"a sequence with gaps is written to a concat file that holds the frame before each gap")
+	flagset.StringVar(&ffconcat, "ffconcat", ffconcat, "Path of the concat file of -ffmpeg, defaults to <name>.ffconcat.txt next to the sequence, overwritten with -f")
+	flagset.StringVar(&edl, "edl", edl, "Print a CMX3600 EDL of sequences at -fps (default 24), listings are separated by '::' and a directory is every sequence under it\n\t"+
+		"the source timecode is the frame number, or set with -tcstart and -tcframe")
+	flagset.StringVar(&otio, "otio", otio, "Print an OpenTimelineIO timeline of sequences at -fps (default 24) with an ImageSequenceReference for each, like -edl")
+	flagset.StringVar(&missing, "missing", missing, "Missing frame policy of the sequences of -otio: error, hold or black")
+	flagset.StringVar(&read_otio, "readotio", read_otio, "Print the listings of the image sequence references of an OpenTimelineIO file, a .otio file may also be a -batchfile")
+	flagset.StringVar(&format, "format", format, "Print listings, and the sequence of -r, the way an application reads them: native, nuke, houdini, maya, rv or blender\n\t"+
+		"ie: nuke prints plate.%04d.exr 1001-1100 and houdini plate.$F4.exr")
+	flagset.StringVar(&watchf, "watch", watchf, "Watch the sequences under a directory and print when frames are added or deleted, and with -range when a sequence has every frame of it")
+	flagset.DurationVar(&poll, "poll", poll, "Poll the -watch directory at this interval instead of using inotify ie: 2s, for network filesystems")
+	flagset.BoolVar(&print_json, "json", print_json, "Print the output of -diff, -health, -info or -watch as json")
 	flagset.BoolVar(&nocolor, "n", false, "Do not add colors to printed output")
 	flagset.BoolVar(&force, "f", force, "Allow for overwriting of exiting files (destination cannot overwrite source unless using 'q' flag)")
+	flagset.BoolVar(&yes, "y", yes, "Answer yes to the confirmation of -batch, -d and -purge, ie: for unattended runs, it does not allow overwriting like -f")
 	flagset.BoolVar(&verbose, "v", verbose, "Send verbose output to stdout")
 	flagset.Parse(os.Args[1:])
 	if len(os.Args) < 1 && len(os.Args) < 3 && !printUsage {
@@ -75,15 +281,71 @@ func InitCommands(out io.Writer) Options {
 	}
 
 	o := Options{
-		Curdir:  strings.TrimRight(curdir, "/"),
-		Reverse: reverse,
-		Copy:    copyf,
-		Move:    move,
-		Delete:  deletef,
-		Reseq:   reseq,
-		Nocolor: nocolor,
-		Force:   force,
-		Verbose: verbose,
+		Curdir:         strings.TrimRight(curdir, "/"),
+		Reverse:        reverse,
+		Copy:           copyf,
+		Move:           move,
+		Delete:         deletef,
+		Reseq:          reseq,
+		Batch:          batch,
+		BatchFile:      batch_file,
+		Op:             op,
+		Dest:           dest,
+		Exclude:        exclude,
+		Frames:         frames,
+		Match:          match,
+		Offset:         offset,
+		Start:          start,
+		Pad:            pad,
+		Map:            mapf,
+		Fill:           fill,
+		Range:          frame_range,
+		Fps:            fps,
+		TcStart:        tc_start,
+		TcFrame:        tc_frame,
+		Link:           link,
+		Preserve:       preserve,
+		BwLimit:        bwlimit,
+		FileLimit:      file_limit,
+		ThrottleFile:   throttle_file,
+		Workers:        workers,
+		FillFrom:       fill_from,
+		Sidecar:        sidecar,
+		Preview:        preview,
+		Progress:       show_progress,
+		Undo:           undof,
+		History:        history,
+		UndoDir:        undo_dir,
+		Trash:          use_trash,
+		TrashLs:        trash_list,
+		Restore:        restore,
+		Purge:          purge,
+		Manifest:       manifestf,
+		ManifestFile:   manifest_file,
+		ManifestFormat: manifest_format,
+		Verify:         verify,
+		Diff:           diff,
+		Sync:           sync,
+		Health:         health,
+		HealthRatio:    health_ratio,
+		HealthWindow:   health_window,
+		Info:           info,
+		Ffmpeg:         ffmpeg,
+		Ffconcat:       ffconcat,
+		Edl:            edl,
+		Otio:           otio,
+		Missing:        missing,
+		ReadOtio:       read_otio,
+		Format:         format,
+		Watch:          watchf,
+		Poll:           poll,
+		Prune:          prune,
+		Checksum:       checksum,
+		Json:           print_json,
+		Nocolor:        nocolor,
+		Force:          force,
+		Yes:            yes,
+		Verbose:        verbose,
 	}
 
 	return o
@@ -0,0 +1,181 @@
+//Package config loads user settings for the file sequencer.  Settings are read
+//from a plain "key = value" file and may be overridden by environment variables.
+//The config file lives at $FSEQ_CONFIG, or $XDG_CONFIG_HOME/filesequence/config
+//(~/.config/filesequence/config when XDG_CONFIG_HOME is unset).
+//
+//Supported keys:
+//undo_dir = directory where the undo log is written (env FSEQ_UNDO_DIR)
+//trash = true|false, move deleted files to the trash (env FSEQ_TRASH)
+//bytes_per_sec = limit of copies and moves ie: 50M, unlimited when unset
+//files_per_sec = limit of files copied or moved per second, unlimited when unset
+//throttle_file = control file checked for new limits while a job is running
+//workers = number of files copied at the same time
+//health_ratio = size of a frame compared to the median around it below which it is truncated
+//health_window = number of frames on each side of a frame used for the median
+//fps = frame rate of timecode ranges and durations ie: 24, 23.976 or 29.97df
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//Struct for user settings
+//UndoDir is the per-user directory holding the undo log
+//Trash is whether deleted files are moved to the trash instead of removed
+//BytesPerSec is the bandwidth limit of copies and moves ie: "50M", empty is unlimited
+//FilesPerSec is the limit of files copied or moved per second, 0 is unlimited
+//ThrottleFile is a control file whose limits are applied while a job runs
+//Workers is the number of files copied at the same time
+//HealthRatio and HealthWindow are the default limits of -health, see seq_health.Thresholds
+//Fps is the default frame rate of timecode, empty when not set
+type Config struct {
+	UndoDir      string
+	Trash        bool
+	BytesPerSec  string
+	FilesPerSec  float64
+	ThrottleFile string
+	Workers      int
+	HealthRatio  float64
+	HealthWindow int
+	Fps          string
+}
+
+//Return the location of the config file
+func ConfigPath() string {
+	if pth := os.Getenv("FSEQ_CONFIG"); pth != "" {
+		return pth
+	}
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "filesequence", "config")
+}
+
+//Return the default settings, used when there is no config file
+func DefaultConfig() Config {
+	return Config{
+		UndoDir:      filepath.Join(xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state")), "filesequence", "undo"),
+		Trash:        true,
+		Workers:      1,
+		HealthRatio:  0.5,
+		HealthWindow: 5,
+	}
+}
+
+//Load the config file if it exists and apply environment overrides
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+	pth := ConfigPath()
+
+	values, read_err := ReadValues(pth)
+	if read_err != nil && !os.IsNotExist(read_err) {
+		return cfg, read_err
+	}
+
+	for key, value := range values {
+		switch key {
+		case "undo_dir":
+			cfg.UndoDir = expandHome(value)
+		case "trash":
+			use_trash, bool_err := strconv.ParseBool(value)
+			if bool_err != nil {
+				return cfg, fmt.Errorf("Setting trash in %s is not true or false", pth)
+			}
+			cfg.Trash = use_trash
+		case "bytes_per_sec":
+			cfg.BytesPerSec = value
+		case "files_per_sec":
+			files_per_sec, float_err := strconv.ParseFloat(value, 64)
+			if float_err != nil || files_per_sec < 0 {
+				return cfg, fmt.Errorf("Setting files_per_sec in %s is not a rate", pth)
+			}
+			cfg.FilesPerSec = files_per_sec
+		case "throttle_file":
+			cfg.ThrottleFile = expandHome(value)
+		case "workers":
+			workers, int_err := strconv.Atoi(value)
+			if int_err != nil || workers < 1 {
+				return cfg, fmt.Errorf("Setting workers in %s is not a number of 1 or more", pth)
+			}
+			cfg.Workers = workers
+		case "health_ratio":
+			ratio, float_err := strconv.ParseFloat(value, 64)
+			if float_err != nil || ratio <= 0 || ratio > 1 {
+				return cfg, fmt.Errorf("Setting health_ratio in %s is not a number above 0 and up to 1", pth)
+			}
+			cfg.HealthRatio = ratio
+		case "health_window":
+			window, int_err := strconv.Atoi(value)
+			if int_err != nil || window < 1 {
+				return cfg, fmt.Errorf("Setting health_window in %s is not a number of 1 or more", pth)
+			}
+			cfg.HealthWindow = window
+		case "fps":
+			cfg.Fps = value
+		default:
+			return cfg, fmt.Errorf("Unknown setting %s in %s", key, pth)
+		}
+	}
+
+	if undo_dir := os.Getenv("FSEQ_UNDO_DIR"); undo_dir != "" {
+		cfg.UndoDir = expandHome(undo_dir)
+	}
+
+	if env_trash := os.Getenv("FSEQ_TRASH"); env_trash != "" {
+		use_trash, bool_err := strconv.ParseBool(env_trash)
+		if bool_err != nil {
+			return cfg, errors.New("FSEQ_TRASH is not true or false")
+		}
+		cfg.Trash = use_trash
+	}
+
+	return cfg, nil
+}
+
+//Read "key = value" lines from a config file, blank lines and lines starting
+//with '#' are skipped
+func ReadValues(pth string) (map[string]string, error) {
+	values := make(map[string]string)
+	f, open_err := os.Open(pth)
+	if open_err != nil {
+		return values, open_err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return values, fmt.Errorf("%s line %d is not 'key = value'", pth, line_num)
+		}
+		values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return values, scanner.Err()
+}
+
+//Return the XDG base directory from env, or the fallback relative to home
+func xdgDir(env string, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, fallback)
+}
+
+//Replace a leading '~' with the user's home directory
+func expandHome(pth string) string {
+	if pth == "~" || strings.HasPrefix(pth, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, pth[1:])
+	}
+	return pth
+}
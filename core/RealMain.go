@@ -3,12 +3,43 @@
 package core
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/export"
 	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/imageinfo"
+	"github.com/mattbro2/filesequence/manifest"
+	"github.com/mattbro2/filesequence/progress"
 	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/seq_diff"
+	"github.com/mattbro2/filesequence/seq_health"
 	"github.com/mattbro2/filesequence/seq_manip"
+	"github.com/mattbro2/filesequence/throttle"
+	"github.com/mattbro2/filesequence/timecode"
+	"github.com/mattbro2/filesequence/trash"
+	"github.com/mattbro2/filesequence/undo"
+	"github.com/mattbro2/filesequence/watch"
 )
 
+//Struct for a sequence of files in the trash, files deleted at the same
+//time from the same sequence are grouped together
+type TrashedSeq struct {
+	DeletionDate time.Time
+	Fseq         reducers.File_seq
+	Items        []trash.Item
+}
+
 //Call the functions and return the data and errors
 func ListMain(curdir string, verbose bool) ([]reducers.File_seq, error) {
 	files, rec_err := filesys.Recurse(curdir, verbose)
@@ -42,26 +73,971 @@ func ReverseSeqMain(fs string) (reducers.File_seq, error) {
 	return fseq, err
 }
 
+//Return the listing of a sequence renumbered by offset ie: "+100", or rebased
+//so its first frame is start, and repadded to pad digits unless pad is -1.
+//The listing may be used as the destination of a copy, move or renumber
+func RenumberListing(fs string, offset string, start string, pad int) (string, error) {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return "", fs_err
+	}
+	if offset != "" && start != "" {
+		return "", errors.New("Only one of offset or start may be given")
+	}
+
+	shift := 0
+	if offset != "" {
+		n, conv_err := strconv.Atoi(offset)
+		if conv_err != nil {
+			return "", fmt.Errorf("Offset %s is not a number", offset)
+		}
+		shift = n
+	}
+	if start != "" {
+		n, conv_err := strconv.Atoi(start)
+		if conv_err != nil {
+			return "", fmt.Errorf("Start %s is not a number", start)
+		}
+		shift = n - fseq.File_list[0]
+	}
+
+	renumbered, rn_err := expanders.Fseq_renumber(fseq, shift, pad)
+	if rn_err != nil {
+		return "", rn_err
+	}
+	return renumbered.F_seq, nil
+}
+
+//Create the options for copy, move, delete and fill from the command line flags,
+//preserve is a comma separated list of attributes ie: "mode,times".  With
+//show_progress the progress is written to stderr, see package progress
+func ManipOptions(force bool, link string, preserve string, show_progress bool, verbose bool) (seq_manip.Options, error) {
+	attrs, pr_err := seq_manip.ParsePreserve(preserve)
+	if pr_err != nil {
+		return seq_manip.Options{}, pr_err
+	}
+	if link_err := seq_manip.ValidLinkMode(link); link_err != nil {
+		return seq_manip.Options{}, link_err
+	}
+	//Links share the attributes of their source, there is nothing to preserve
+	if preserve != "" && (link == "hard" || link == "sym" || link == "relative-sym") {
+		return seq_manip.Options{}, fmt.Errorf("-preserve can not be used with -link %s, links keep the attributes of the source", link)
+	}
+	opts := seq_manip.Options{
+		Force:    force,
+		Link:     link,
+		Preserve: attrs,
+		Verbose:  verbose,
+	}
+	if show_progress {
+		opts.Progress = progress.New(os.Stderr)
+	}
+	return opts, nil
+}
+
+//Create the rate limiter of copies and moves from the command line flags,
+//bwlimit is bytes per second ie: "50M" and empty or 0 is unlimited.  When a
+//throttle_file is given the limits are read from it while the job runs, and
+//SIGUSR1 or SIGUSR2 halve or double them.  Limits given on the command line
+//win over a throttle_file that already exists until it changes.  Returns nil
+//when nothing is limited
+func Limiter(bwlimit string, files_per_sec float64, throttle_file string) (*throttle.Limiter, error) {
+	var bytes_per_sec float64
+	if bwlimit != "" {
+		var parse_err error
+		bytes_per_sec, parse_err = throttle.ParseBytes(bwlimit)
+		if parse_err != nil {
+			return nil, parse_err
+		}
+	}
+	if files_per_sec < 0 {
+		return nil, fmt.Errorf("Files per second %v can not be negative", files_per_sec)
+	}
+	if bytes_per_sec == 0 && files_per_sec == 0 && throttle_file == "" {
+		return nil, nil
+	}
+	limit := throttle.New(bytes_per_sec, files_per_sec, os.Stderr)
+	if throttle_file != "" {
+		if watch_err := limit.WatchFile(throttle_file, bytes_per_sec != 0 || files_per_sec != 0); watch_err != nil {
+			return nil, watch_err
+		}
+	}
+	limit.WatchSignals()
+	return limit, nil
+}
+
+//Return a frame range with its timecode ranges ie: "01:00:10:00-01:00:12:00"
+//converted to frame numbers at fps.  tc_start is the timecode of frame
+//tc_frame, or of the first frame of the fileseq listing when tc_frame is -1.
+//Without tc_start 00:00:00:00 is frame 0.  A frame range without timecode is
+//returned as it is
+func TimecodeFrames(frames string, fps string, tc_start string, tc_frame int, listing string) (string, error) {
+	has_timecode := false
+	for _, part := range strings.Split(frames, ",") {
+		if timecode.IsTimecode(strings.SplitN(strings.TrimSpace(part), "-", 2)[0]) {
+			has_timecode = true
+		}
+	}
+	if !has_timecode {
+		return frames, nil
+	}
+	if fps == "" {
+		return "", errors.New("A timecode range " + frames + " needs a frame rate, use -fps")
+	}
+	rate, rate_err := timecode.ParseRate(fps)
+	if rate_err != nil {
+		return "", rate_err
+	}
+	if tc_start == "" {
+		tc_start = "00:00:00:00"
+		tc_frame = 0
+	}
+	if tc_frame < 0 {
+		fseq, fs_err := expanders.Fseq_to_object(listing)
+		if listing == "" || fs_err != nil || len(fseq.File_list) == 0 {
+			return "", errors.New("A timecode range " + frames + " with -tcstart needs -tcframe, the frame at -tcstart")
+		}
+		tc_frame = fseq.File_list[0]
+	}
+	return timecode.FrameRange(frames, rate, tc_start, tc_frame)
+}
+
+//Return a listing with its number of frames and duration at fps ie:
+//"plate.[1001-1100].exr  100 frames  00:00:04:04".  When tc_start is set, the
+//timecode of the first and last frame is added, tc_start is the timecode of
+//frame tc_frame, or of the first frame of the sequence when tc_frame is -1
+func DurationListing(fs reducers.File_seq, rate timecode.Rate, tc_start string, tc_frame int) (string, error) {
+	if !strings.Contains(fs.Base, "@") || len(fs.File_list) == 0 {
+		return fs.F_seq, nil
+	}
+	first, last := fs.File_list[0], fs.File_list[len(fs.File_list)-1]
+	listing := fmt.Sprintf("%s  %d frames  %s", fs.F_seq, last-first+1, timecode.FromFrames(last-first+1, rate))
+	if tc_start == "" {
+		return listing, nil
+	}
+	start, tc_err := timecode.ToFrames(tc_start, rate)
+	if tc_err != nil {
+		return listing, tc_err
+	}
+	if tc_frame < 0 {
+		tc_frame = first
+	}
+	tc_in := timecode.FromFrames(start+first-tc_frame, rate)
+	tc_out := timecode.FromFrames(start+last-tc_frame, rate)
+	return listing + "  " + tc_in + "-" + tc_out, nil
+}
+
+//Return a fileseq listing filtered to the frames of a frame range
+//ie: "1001-1050" or "1001-1100:2"
+func FilterListing(fs string, frames string) (string, error) {
+	frame_list, range_err := expanders.Frame_range(frames)
+	if range_err != nil {
+		return "", range_err
+	}
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return "", fs_err
+	}
+	filtered, filter_err := expanders.Fseq_filter(fseq, frame_list)
+	if filter_err != nil {
+		return "", filter_err
+	}
+	return filtered.F_seq, nil
+}
+
+//Return the dest listing of a source listing filtered to the frames of a frame
+//range.  A dest with as many frames as the whole source is filtered to the same
+//positions as the source frames, a dest with as many frames as the filtered
+//source is used as it is
+func FilterDestListing(fs string, fd string, frames string) (string, error) {
+	frame_list, range_err := expanders.Frame_range(frames)
+	if range_err != nil {
+		return "", range_err
+	}
+	fs_source, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return "", fs_err
+	}
+	fs_dest, fd_err := expanders.Fseq_to_object(fd)
+	if fd_err != nil {
+		return "", fd_err
+	}
+	filtered, filter_err := expanders.Fseq_filter(fs_source, frame_list)
+	if filter_err != nil {
+		return "", filter_err
+	}
+
+	if len(fs_dest.File_list) == len(filtered.File_list) {
+		return fd, nil
+	}
+	if len(fs_dest.File_list) != len(fs_source.File_list) {
+		return "", fmt.Errorf("%s must have %d frames like the source or %d frames like the filtered source",
+			fd, len(fs_source.File_list), len(filtered.File_list))
+	}
+
+	wanted := make(map[int]bool)
+	for _, f := range filtered.File_list {
+		wanted[f] = true
+	}
+	var positions []int
+	for i, f := range fs_source.File_list {
+		if wanted[f] {
+			positions = append(positions, fs_dest.File_list[i])
+		}
+	}
+	filtered_dest, dest_err := expanders.Fseq_filter(fs_dest, positions)
+	if dest_err != nil {
+		return "", dest_err
+	}
+	return filtered_dest.F_seq, nil
+}
+
+//Test if a destination is a template ie: /archive/{name}.{frame:04d}.{ext} or a
+//directory, rather than a fileseq listing
+func IsTemplate(fd string) bool {
+	if strings.Contains(fd, "{") || strings.HasSuffix(fd, "/") {
+		return true
+	}
+	return filesys.IsDir(fd)
+}
+
+//Return the dest listing of a source listing rendered from a template or
+//directory.  match is an optional regex whose groups are matched against the
+//source path and may be used in the template ie: {shot} or {1}
+func TemplateListing(fs string, tmpl string, match string) (string, error) {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return "", fs_err
+	}
+	captures, match_err := expanders.Template_captures(fseq, match)
+	if match_err != nil {
+		return "", match_err
+	}
+	dest, tmpl_err := expanders.Fseq_template(fseq, tmpl, captures)
+	if tmpl_err != nil {
+		return "", tmpl_err
+	}
+	return dest.F_seq, nil
+}
+
 //Call seq_manip.CopySeq() using source and dest fileseq listings
-func CopySeqMain(fs string, fd string, force bool, verbose bool) error {
-	err := seq_manip.CopySeq(fs, fd, force, verbose)
+func CopySeqMain(fs string, fd string, opts seq_manip.Options) error {
+	err := seq_manip.CopySeq(fs, fd, opts)
 	return err
 }
 
-//Call seq_manip.MoveSeq() using source and dest fileseq listings
-func MoveSeqMain(fs string, fd string, force bool, verbose bool) error {
-	err := seq_manip.MoveSeq(fs, fd, force, verbose)
+//Call seq_manip.CopyMapSeq() using source and dest fileseq listings and a
+//frame mapping expression
+func CopyMapSeqMain(fs string, fd string, expr string, opts seq_manip.Options) error {
+	err := seq_manip.CopyMapSeq(fs, fd, expr, opts)
 	return err
 }
 
-//Call seq_manip.ReSeq() using source and dest fileseq listings
-func ReSeqMain(fs string, fd string, verbose bool) error {
-	err := seq_manip.ReSeq(fs, fd, verbose)
-	return err
+//Return the source and dest files a frame mapping expression would copy,
+//without copying anything
+func MapPreviewMain(fs string, fd string, expr string) ([]string, []string, error) {
+	fs_source, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return nil, nil, fs_err
+	}
+	fs_dest, fd_err := expanders.Fseq_to_object(fd)
+	if fd_err != nil {
+		return nil, nil, fd_err
+	}
+	return seq_manip.MapFileLists(fs_source, fs_dest, expr, true)
 }
 
-//Call seq_manip,DeleteSeq() with fileseq listing
-func DeleteSeqMain(fs string, force bool, verbose bool) error {
-	err := seq_manip.DeleteSeq(fs, force, verbose)
-	return err
+//Call seq_manip.FillSeq() to create the missing frames of a fileseq listing,
+//frames is a frame range ie: "1001-1100" or empty to fill the gaps between the
+//first and last online frames
+func FillSeqMain(fs string, frames string, from string, sidecar bool, opts seq_manip.Options) ([]string, error) {
+	var frame_list []int
+	if frames != "" {
+		var range_err error
+		frame_list, range_err = expanders.Frame_range(frames)
+		if range_err != nil {
+			return nil, range_err
+		}
+	}
+	created, err := seq_manip.FillSeq(fs, frame_list, from, sidecar, opts)
+	return created, err
+}
+
+//Call seq_manip.MoveSeq() using source and dest fileseq listings, the move is
+//recorded in the undo log
+func MoveSeqMain(fs string, fd string, opts seq_manip.Options, undo_dir string) error {
+	pairs, err := seq_manip.MoveSeq(fs, fd, opts)
+	return recordOp(undo_dir, "move", pairs, err, opts.Verbose)
+}
+
+//Call seq_manip.ReSeq() using source and dest fileseq listings, the renumber and
+//any frames it overwrote are recorded in the undo log
+func ReSeqMain(fs string, fd string, use_trash bool, opts seq_manip.Options, undo_dir string) error {
+	pairs, err := seq_manip.ReSeq(fs, fd, use_trash, opts)
+	return recordOp(undo_dir, "reseq", pairs, err, opts.Verbose)
+}
+
+//Call seq_manip,DeleteSeq() with fileseq listing, the delete is recorded in
+//the undo log when files are moved to the trash
+func DeleteSeqMain(fs string, use_trash bool, opts seq_manip.Options, undo_dir string) error {
+	pairs, err := seq_manip.DeleteSeq(fs, use_trash, opts)
+	return recordOp(undo_dir, "delete", pairs, err, opts.Verbose)
+}
+
+//List the trash and reduce the trashed files to sequences, oldest first
+func TrashListMain() ([]TrashedSeq, error) {
+	var trashed []TrashedSeq
+	items, list_err := trash.List()
+	if list_err != nil {
+		return trashed, list_err
+	}
+
+	by_date := make(map[time.Time][]trash.Item)
+	var dates []time.Time
+	for _, item := range items {
+		if _, ok := by_date[item.DeletionDate]; !ok {
+			dates = append(dates, item.DeletionDate)
+		}
+		by_date[item.DeletionDate] = append(by_date[item.DeletionDate], item)
+	}
+
+	for _, date := range dates {
+		by_path := make(map[string]trash.Item)
+		var paths []string
+		for _, item := range by_date[date] {
+			by_path[item.Path] = item
+			paths = append(paths, item.Path)
+		}
+		reduced, red_err := reducers.ReduceBase(paths)
+		if red_err != nil {
+			return trashed, red_err
+		}
+		file_seqs, fseq_err := reducers.ReduceFileseq(reduced)
+		if fseq_err != nil {
+			return trashed, fseq_err
+		}
+		sort.Slice(file_seqs, func(i, j int) bool {
+			return file_seqs[i].F_seq < file_seqs[j].F_seq
+		})
+		for _, fseq := range file_seqs {
+			files, _ := expanders.Fseq_expand(fseq)
+			ts := TrashedSeq{DeletionDate: date, Fseq: fseq}
+			for _, f := range files {
+				ts.Items = append(ts.Items, by_path[f])
+			}
+			trashed = append(trashed, ts)
+		}
+	}
+	return trashed, nil
+}
+
+//Restore the files of a fileseq listing from the trash.  When a file has been
+//trashed more than once the most recent is restored
+func RestoreMain(fs string, verbose bool) error {
+	items, match_err := trashedItems(fs)
+	if match_err != nil {
+		return match_err
+	}
+
+	latest := make(map[string]trash.Item)
+	var paths []string
+	for _, item := range items {
+		if _, ok := latest[item.Path]; !ok {
+			paths = append(paths, item.Path)
+		}
+		latest[item.Path] = item
+	}
+
+	for _, pth := range paths {
+		if verbose {
+			fmt.Printf("restoring %s\n", pth)
+		}
+		rs_err := trash.Restore(latest[pth])
+		if rs_err != nil {
+			return rs_err
+		}
+	}
+	return nil
+}
+
+//Permanently remove the files of a fileseq listing from the trash, a listing
+//of "all" empties the trash
+func PurgeMain(fs string, verbose bool) error {
+	var items []trash.Item
+	var list_err error
+	if fs == "all" {
+		items, list_err = trash.List()
+	} else {
+		items, list_err = trashedItems(fs)
+	}
+	if list_err != nil {
+		return list_err
+	}
+
+	for _, item := range items {
+		if verbose {
+			fmt.Printf("purging %s\n", item.Path)
+		}
+		pg_err := trash.Purge(item)
+		if pg_err != nil {
+			return pg_err
+		}
+	}
+	return nil
+}
+
+//Return the trashed items whose original path is in the fileseq listing,
+//oldest first.  The listing does not need to be online
+func trashedItems(fs string) ([]trash.Item, error) {
+	var matched []trash.Item
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		fseq = reducers.File_seq{Base: fs, File_num: map[int]string{0: "0"}, File_list: []int{0}, F_seq: fs}
+	}
+	files, _ := expanders.Fseq_expand(fseq)
+	wanted := make(map[string]bool)
+	for _, f := range files {
+		abs_f, abs_err := filepath.Abs(f)
+		if abs_err != nil {
+			return matched, abs_err
+		}
+		wanted[abs_f] = true
+	}
+
+	items, list_err := trash.List()
+	if list_err != nil {
+		return matched, list_err
+	}
+	for _, item := range items {
+		if wanted[item.Path] {
+			matched = append(matched, item)
+		}
+	}
+	if len(matched) == 0 {
+		return matched, errors.New(fs + " has no files in the trash")
+	}
+	return matched, nil
+}
+
+//Write a checksum manifest of the frames of a fileseq listing to pth, or next
+//to the sequence ie: plate.manifest.sha256 when pth is empty.  format is sha256
+//or mhl, and is taken from the extension of pth when it is .mhl.  An existing
+//manifest is only overwritten with force
+//Returns the path of the manifest and its frames
+func ManifestMain(fs string, pth string, format string, force bool) (string, []manifest.Entry, error) {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return pth, nil, fs_err
+	}
+	files, files_err := expanders.Fseq_expand(fseq)
+	if files_err != nil {
+		return pth, nil, files_err
+	}
+	for _, x := range files {
+		isfile, _ := filesys.IsFile(x)
+		if !isfile {
+			return pth, nil, errors.New(fseq.F_seq + " is not completely online, " + x + " is missing")
+		}
+	}
+	if pth == "" {
+		pth = strings.Replace(fseq.Base, "@", "manifest", 1)
+		pth = strings.TrimSuffix(pth, filepath.Ext(pth)) + "." + format
+	} else if manifest.FormatOf(pth) == manifest.Mhl {
+		format = manifest.Mhl
+	}
+	entries, create_err := manifest.Create(files, pth, format, fseq.F_seq, force)
+	return pth, entries, create_err
+}
+
+//Check the frames of a manifest on disk.  Frames of the same sequences that
+//are in the directories of the manifest but not in it are reported as extra
+func VerifyMain(pth string) (manifest.Report, error) {
+	entries, read_err := manifest.Read(pth)
+	if read_err != nil {
+		return manifest.Report{}, read_err
+	}
+	report, verify_err := manifest.Verify(entries)
+	if verify_err != nil {
+		return report, verify_err
+	}
+
+	known := make(map[string]bool)
+	var paths []string
+	for _, e := range entries {
+		known[e.Path] = true
+		paths = append(paths, e.Path)
+	}
+	bases, red_err := reducers.ReduceBase(paths)
+	if red_err != nil {
+		return report, red_err
+	}
+	dirs := make(map[string]bool)
+	for base, _ := range bases {
+		if strings.Contains(base, "@") {
+			dirs[filepath.Dir(base)] = true
+		}
+	}
+	for dir, _ := range dirs {
+		dir_entries, dir_err := ioutil.ReadDir(dir)
+		if dir_err != nil {
+			return report, dir_err
+		}
+		var on_disk []string
+		for _, x := range dir_entries {
+			if !x.IsDir() {
+				on_disk = append(on_disk, filepath.Join(dir, x.Name()))
+			}
+		}
+		disk_bases, red_err := reducers.ReduceBase(on_disk)
+		if red_err != nil {
+			return report, red_err
+		}
+		for base, frames := range disk_bases {
+			if _, ok := bases[base]; !ok || !strings.Contains(base, "@") {
+				continue
+			}
+			for _, num := range frames {
+				x := strings.Replace(base, "@", num, 1)
+				if !known[x] {
+					report.Extra = append(report.Extra, x)
+				}
+			}
+		}
+	}
+	sort.Strings(report.Extra)
+	return report, nil
+}
+
+//Compare two fileseq listings, or two directories whose sequences are matched
+//by their path relative to each directory.  Frames are compared by size, and by
+//checksum as well when checksum is set
+func DiffMain(old string, new string, checksum bool) ([]seq_diff.SeqDiff, error) {
+	if filesys.IsDir(old) && filesys.IsDir(new) {
+		old = strings.TrimRight(old, "/")
+		new = strings.TrimRight(new, "/")
+		old_seqs, list_err := ListMain(old, false)
+		if list_err != nil {
+			return nil, list_err
+		}
+		new_seqs, list_err := ListMain(new, false)
+		if list_err != nil {
+			return nil, list_err
+		}
+		return seq_diff.CompareTrees(old_seqs, old, new_seqs, new, checksum)
+	}
+
+	old_seq, old_err := onlineFrames(old)
+	if old_err != nil {
+		return nil, old_err
+	}
+	new_seq, new_err := onlineFrames(new)
+	if new_err != nil {
+		return nil, new_err
+	}
+	d, cmp_err := seq_diff.Compare(old_seq, new_seq, checksum)
+	if cmp_err != nil {
+		return nil, cmp_err
+	}
+	d.Sequence = new
+	return []seq_diff.SeqDiff{d}, nil
+}
+
+//Return the File_seq of a listing with only the frames that are online
+func onlineFrames(fs string) (reducers.File_seq, error) {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return fseq, fs_err
+	}
+	online := reducers.File_seq{Base: fseq.Base, File_num: make(map[int]string), F_seq: fseq.F_seq}
+	for _, f := range fseq.File_list {
+		isfile, _ := filesys.IsFile(strings.Replace(fseq.Base, "@", fseq.File_num[f], 1))
+		if isfile {
+			online.File_num[f] = fseq.File_num[f]
+			online.File_list = append(online.File_list, f)
+		}
+	}
+	return online, nil
+}
+
+//Struct for the sync of one sequence, Sequence is relative to the source
+//directory when trees are synced
+type SyncedSeq struct {
+	Sequence string
+	Result   seq_manip.SyncResult
+}
+
+//Sync the frames of a source listing to a dest listing, or every sequence of a
+//source directory to the same path under a dest directory.  See
+//seq_manip.SyncSeq, with prune the sequences of the dest directory that are not
+//in the source are deleted as well.  Deletes to the trash are recorded in the
+//undo log as one operation
+func SyncMain(source string, dest string, checksum bool, prune bool, use_trash bool, preview bool, opts seq_manip.Options, undo_dir string) ([]SyncedSeq, error) {
+	var synced []SyncedSeq
+	var pairs []undo.Pair
+	if !filesys.IsDir(source) {
+		result, seq_pairs, sync_err := seq_manip.SyncSeq(source, dest, checksum, prune, use_trash, preview, opts)
+		synced = append(synced, SyncedSeq{Sequence: source, Result: result})
+		return synced, recordOp(undo_dir, "delete", seq_pairs, sync_err, opts.Verbose)
+	}
+
+	source = strings.TrimRight(source, "/")
+	dest = strings.TrimRight(dest, "/")
+	source_seqs, list_err := ListMain(source, false)
+	if list_err != nil {
+		return synced, list_err
+	}
+	var dest_seqs []reducers.File_seq
+	if filesys.IsDir(dest) {
+		dest_seqs, list_err = ListMain(dest, false)
+		if list_err != nil {
+			return synced, list_err
+		}
+	}
+	sort.Slice(source_seqs, func(i, j int) bool { return source_seqs[i].F_seq < source_seqs[j].F_seq })
+
+	//Files that are not part of a sequence are not synced
+	in_source := make(map[string]bool)
+	for _, x := range source_seqs {
+		if !strings.Contains(x.Base, "@") {
+			continue
+		}
+		rel, rel_err := filepath.Rel(source, x.Base)
+		if rel_err != nil {
+			return synced, rel_err
+		}
+		in_source[rel] = true
+		fs := seqListing(x)
+		fd := filepath.Join(dest, strings.TrimPrefix(fs, source+string(filepath.Separator)))
+		result, seq_pairs, sync_err := seq_manip.SyncSeq(fs, fd, checksum, prune, use_trash, preview, opts)
+		pairs = append(pairs, seq_pairs...)
+		synced = append(synced, SyncedSeq{Sequence: strings.TrimPrefix(fs, source+string(filepath.Separator)), Result: result})
+		if sync_err != nil {
+			return synced, recordOp(undo_dir, "delete", pairs, fmt.Errorf("%s - %v", fs, sync_err), opts.Verbose)
+		}
+	}
+
+	if prune {
+		for _, x := range dest_seqs {
+			rel, rel_err := filepath.Rel(dest, x.Base)
+			if rel_err != nil {
+				return synced, rel_err
+			}
+			if !strings.Contains(x.Base, "@") || in_source[rel] {
+				continue
+			}
+			files, files_err := expanders.Fseq_expand(x)
+			if files_err != nil {
+				return synced, files_err
+			}
+			result := seq_manip.SyncResult{Deleted: files}
+			if !preview {
+				seq_pairs, del_err := seq_manip.DeleteSeq(seqListing(x), use_trash, opts)
+				pairs = append(pairs, seq_pairs...)
+				if del_err != nil {
+					return synced, recordOp(undo_dir, "delete", pairs, del_err, opts.Verbose)
+				}
+			}
+			synced = append(synced, SyncedSeq{Sequence: strings.TrimPrefix(seqListing(x), dest+string(filepath.Separator)), Result: result})
+		}
+	}
+	return synced, recordOp(undo_dir, "delete", pairs, nil, opts.Verbose)
+}
+
+//Check the frames of a fileseq listing, or of every sequence under a
+//directory, for frames that are missing, 0 bytes, truncated or unreadable.
+//See seq_health.Thresholds for ratio and window
+func HealthMain(target string, ratio float64, window int, verbose bool) ([]seq_health.Report, error) {
+	var reports []seq_health.Report
+	if ratio <= 0 || ratio > 1 {
+		return reports, fmt.Errorf("Health ratio %v must be above 0 and up to 1", ratio)
+	}
+	if window < 1 {
+		return reports, fmt.Errorf("Health window %d must be 1 or more", window)
+	}
+	t := seq_health.Thresholds{Ratio: ratio, Window: window}
+
+	if !filesys.IsDir(target) {
+		fseq, fs_err := expanders.Fseq_to_object(target)
+		if fs_err != nil {
+			return reports, fs_err
+		}
+		return append(reports, seq_health.Check(fseq, t)), nil
+	}
+
+	file_seqs, list_err := ListMain(strings.TrimRight(target, "/"), verbose)
+	if list_err != nil {
+		return reports, list_err
+	}
+	sort.Slice(file_seqs, func(i, j int) bool { return file_seqs[i].F_seq < file_seqs[j].F_seq })
+	for _, x := range file_seqs {
+		//Files that are not part of a sequence are not checked
+		if !strings.Contains(x.Base, "@") {
+			continue
+		}
+		report := seq_health.Check(x, t)
+		report.Sequence = seqListing(x)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+//Read the headers of the frames of a fileseq listing, see imageinfo.Summarize
+func InfoMain(fs string) (imageinfo.SeqInfo, error) {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return imageinfo.SeqInfo{}, fs_err
+	}
+	return imageinfo.Summarize(fseq)
+}
+
+//Return the ffmpeg input arguments of the online frames of a fileseq listing at
+//fps, 24 when empty.  A sequence with gaps is written to an ffmpeg concat file
+//at concat_pth, or next to the sequence ie: plate.ffconcat.txt when it is empty,
+//an existing concat file is only overwritten with force
+func FfmpegMain(fs string, fps string, concat_pth string, force bool) ([]string, error) {
+	if fps == "" {
+		fps = "24"
+	}
+	rate, rate_err := timecode.ParseRate(fps)
+	if rate_err != nil {
+		return nil, rate_err
+	}
+	fseq, fs_err := onlineFrames(fs)
+	if fs_err != nil {
+		return nil, fs_err
+	}
+	if len(fseq.File_list) == 0 {
+		return nil, errors.New(fs + " has no online frames")
+	}
+	if !export.HasGaps(fseq) {
+		return export.FfmpegArgs(fseq, rate)
+	}
+	if concat_pth == "" {
+		concat_pth = strings.Replace(fseq.Base, "@", "ffconcat", 1)
+		concat_pth = strings.TrimSuffix(concat_pth, filepath.Ext(concat_pth)) + ".txt"
+	}
+	return export.FfmpegConcat(fseq, rate, concat_pth, force)
+}
+
+//Write fileseq listings as an edit in format, edl or otio, and return it.
+//Listings are separated by '::', a directory is every sequence under it in
+//order.  fps is the frame rate, 24 when empty.  The source timecode of an EDL
+//is the frame number, or offset so frame tc_frame is tc_start when it is set,
+//the first frame of each sequence when tc_frame is -1.
+//missing is the missing frame policy of OpenTimelineIO: error, hold or black
+func ExportMain(listings string, format string, fps string, tc_start string, tc_frame int, missing string) (string, error) {
+	if fps == "" {
+		fps = "24"
+	}
+	rate, rate_err := timecode.ParseRate(fps)
+	if rate_err != nil {
+		return "", rate_err
+	}
+	var seqs []reducers.File_seq
+	for _, x := range strings.Split(listings, "::") {
+		if !filesys.IsDir(x) {
+			fseq, fs_err := expanders.Fseq_to_object(x)
+			if fs_err != nil {
+				return "", fs_err
+			}
+			seqs = append(seqs, fseq)
+			continue
+		}
+		file_seqs, list_err := ListMain(strings.TrimRight(x, "/"), false)
+		if list_err != nil {
+			return "", list_err
+		}
+		sort.Slice(file_seqs, func(i, j int) bool { return file_seqs[i].F_seq < file_seqs[j].F_seq })
+		for _, fseq := range file_seqs {
+			if strings.Contains(fseq.Base, "@") {
+				seqs = append(seqs, fseq)
+			}
+		}
+	}
+
+	var out strings.Builder
+	switch format {
+	case "edl":
+		var tc_offsets []int
+		if tc_start != "" {
+			start, tc_err := timecode.ToFrames(tc_start, rate)
+			if tc_err != nil {
+				return "", tc_err
+			}
+			for _, fs := range seqs {
+				frame := tc_frame
+				if frame < 0 && len(fs.File_list) > 0 {
+					frame = fs.File_list[0]
+				}
+				tc_offsets = append(tc_offsets, start-frame)
+			}
+		}
+		if edl_err := export.WriteEdl(&out, "fileseq", seqs, rate, tc_offsets); edl_err != nil {
+			return "", edl_err
+		}
+	case "otio":
+		if otio_err := export.WriteOtio(&out, "fileseq", seqs, rate, missing); otio_err != nil {
+			return "", otio_err
+		}
+	default:
+		return "", errors.New("Export format must be edl or otio, not " + format)
+	}
+	return out.String(), nil
+}
+
+//Return the listings of the image sequence references of an OpenTimelineIO file
+func OtioListings(pth string) ([]string, error) {
+	var listings []string
+	seqs, read_err := export.ReadOtio(pth)
+	for _, x := range seqs {
+		listings = append(listings, seqListing(x))
+	}
+	return listings, read_err
+}
+
+//Watch the sequences under dir and call emit for each event until an error
+//stops the watch.  frames is the frame range a sequence must have to be
+//complete, empty for no complete events.  With a poll interval the tree is
+//polled instead of using inotify
+func WatchMain(dir string, frames string, poll time.Duration, emit func(watch.Event)) error {
+	if !filesys.IsDir(dir) {
+		return errors.New(dir + " is not a directory")
+	}
+	opts := watch.Options{Poll: poll, ForcePoll: poll > 0}
+	if frames != "" {
+		target, range_err := expanders.Frame_range(frames)
+		if range_err != nil {
+			return range_err
+		}
+		opts.Target = target
+	}
+	return watch.Watch(strings.TrimRight(dir, "/"), opts, emit)
+}
+
+//Return the listing of a File_seq, sequences of a single frame are kept in
+//brackets so the listing is still a sequence ie: plate.[1001].exr
+func seqListing(fs reducers.File_seq) string {
+	if strings.Contains(fs.Base, "@") && len(fs.File_list) == 1 {
+		return strings.Replace(fs.Base, "@", "["+fs.File_num[fs.File_list[0]]+"]", 1)
+	}
+	return fs.F_seq
+}
+
+//Reverse an operation from the undo log by id, or "last" for the most recent
+func UndoMain(id string, verbose bool, undo_dir string) error {
+	op, load_err := undo.Load(undo_dir, id)
+	if load_err != nil {
+		return load_err
+	}
+	undo_err := seq_manip.UndoOp(op, verbose)
+	if undo_err != nil {
+		return undo_err
+	}
+	return undo.Remove(undo_dir, op.Id)
+}
+
+//Return the operations in the undo log, oldest first
+func HistoryMain(undo_dir string) ([]undo.Operation, error) {
+	ops, err := undo.List(undo_dir)
+	return ops, err
+}
+
+//Return the fileseq listings a batch operation applies to, either the
+//sequences found under dir or the listings in listfile, one per line.  Lines
+//of listfile may also be "source::dest" pairs.  A listfile ending in .otio is
+//read as an OpenTimelineIO file, see OtioListings.  match and exclude are
+//optional regexes the listings must or must not match
+func BatchListings(dir string, listfile string, match string, exclude string, verbose bool) ([]string, error) {
+	var listings []string
+	if (dir == "") == (listfile == "") {
+		return nil, errors.New("Give one of a batch directory or a batch file")
+	}
+	if dir != "" {
+		file_seqs, list_err := ListMain(strings.TrimRight(dir, "/"), verbose)
+		if list_err != nil {
+			return nil, list_err
+		}
+		for _, x := range file_seqs {
+			//Files that are not part of a sequence are skipped
+			if !strings.Contains(x.Base, "@") {
+				continue
+			}
+			listings = append(listings, seqListing(x))
+		}
+	} else if strings.EqualFold(filepath.Ext(listfile), ".otio") {
+		otio_listings, otio_err := OtioListings(listfile)
+		if otio_err != nil {
+			return nil, otio_err
+		}
+		listings = otio_listings
+	} else {
+		f, open_err := os.Open(listfile)
+		if open_err != nil {
+			return nil, open_err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			listings = append(listings, line)
+		}
+		if scan_err := scanner.Err(); scan_err != nil {
+			return nil, scan_err
+		}
+	}
+
+	var match_regex, exclude_regex *regexp.Regexp
+	if match != "" {
+		var reg_err error
+		if match_regex, reg_err = regexp.Compile(match); reg_err != nil {
+			return nil, fmt.Errorf("Invalid match %s - %v", match, reg_err)
+		}
+	}
+	if exclude != "" {
+		var reg_err error
+		if exclude_regex, reg_err = regexp.Compile(exclude); reg_err != nil {
+			return nil, fmt.Errorf("Invalid exclude %s - %v", exclude, reg_err)
+		}
+	}
+	var filtered []string
+	for _, x := range listings {
+		source := strings.Split(x, "::")[0]
+		if match_regex != nil && !match_regex.MatchString(source) {
+			continue
+		}
+		if exclude_regex != nil && exclude_regex.MatchString(source) {
+			continue
+		}
+		filtered = append(filtered, x)
+	}
+	sort.Strings(filtered)
+	if len(filtered) == 0 {
+		return nil, errors.New("No sequences matched")
+	}
+	return filtered, nil
+}
+
+//Record the completed renames of an operation in the undo log.  Partially
+//completed operations are recorded as well so they can still be reversed
+func recordOp(undo_dir string, kind string, pairs []undo.Pair, op_err error, verbose bool) error {
+	if len(pairs) == 0 {
+		return op_err
+	}
+	op, rec_err := undo.Record(undo_dir, kind, pairs)
+	if rec_err != nil {
+		if op_err != nil {
+			return fmt.Errorf("%v\nUnable to record undo log - %v", op_err, rec_err)
+		}
+		return fmt.Errorf("Unable to record undo log - %v", rec_err)
+	}
+	if op_err != nil {
+		return fmt.Errorf("%v\nThe files that completed may be reversed with -undo %s", op_err, op.Id)
+	}
+	if verbose {
+		fmt.Printf("recorded %s, reverse with -undo %s\n", op.Id, op.Id)
+	}
+	return op_err
 }
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenumberListing(t *testing.T) {
+	tests := []struct {
+		name    string
+		fs      string
+		offset  string
+		start   string
+		pad     int
+		want    string
+		wantErr bool
+	}{
+		{"offset", "plate.[1001-1100].exr", "+100", "", -1, "plate.[1101-1200].exr", false},
+		{"negative offset", "plate.[1001-1100].exr", "-1000", "", -1, "plate.[0001-0100].exr", false},
+		{"negative offset below 0", "plate.[1001-1100].exr", "-1002", "", -1, "", true},
+		{"offset keeps gaps", "plate.[1001-1010,1020].exr", "10", "", -1, "plate.[1011-1020,1030].exr", false},
+		{"start", "plate.[0001-0100].exr", "", "1001", -1, "plate.[1001-1100].exr", false},
+		{"start below the first frame", "plate.[1001-1100].exr", "", "1", -1, "plate.[0001-0100].exr", false},
+		{"width grows past 999", "plate.[0990-0999].exr", "+10", "", -1, "plate.[1000-1009].exr", false},
+		{"unpadded width grows past 999", "plate.[990-999].exr", "+10", "", -1, "plate.[1000-1009].exr", false},
+		{"mixed width stays unpadded", "plate.[8-12].exr", "+1", "", -1, "plate.[9-13].exr", false},
+		{"pad", "plate.[1-10].exr", "", "", 4, "plate.[0001-0010].exr", false},
+		{"pad with offset", "plate.[1-10].exr", "+1000", "", 6, "plate.[001001-001010].exr", false},
+		{"pad 0 removes padding", "plate.[0001-0010].exr", "", "", 0, "plate.[1-10].exr", false},
+		{"pad below the width", "plate.[1001-1100].exr", "", "", 3, "", true},
+		{"pad too small after offset", "plate.[0990-0999].exr", "+10", "", 3, "", true},
+		{"offset and start", "plate.[1001-1100].exr", "+1", "1", -1, "", true},
+		{"offset not a number", "plate.[1001-1100].exr", "ten", "", -1, "", true},
+		{"start not a number", "plate.[1001-1100].exr", "", "one", -1, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rn_err := RenumberListing(tt.fs, tt.offset, tt.start, tt.pad)
+			if (rn_err != nil) != tt.wantErr {
+				t.Fatalf("RenumberListing() error = %v, wantErr %v", rn_err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenumberListing() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+//The limits on the command line win over an existing throttle file, which is
+//used when there are none
+func TestLimiterThrottleFile(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "throttle")
+	if write_err := ioutil.WriteFile(pth, []byte("bytes_per_sec = 1M\nfiles_per_sec = 20\n"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	tests := []struct {
+		name          string
+		bwlimit       string
+		files_per_sec float64
+		want_bytes    float64
+		want_files    float64
+	}{
+		{"command line", "5M", 0, 5e6, 0},
+		{"files on the command line", "", 10, 0, 10},
+		{"throttle file", "", 0, 1e6, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, limit_err := Limiter(tt.bwlimit, tt.files_per_sec, pth)
+			if limit_err != nil {
+				t.Fatalf("Limiter() error = %v", limit_err)
+			}
+			if bytes_per_sec, files_per_sec := limit.Rates(); bytes_per_sec != tt.want_bytes || files_per_sec != tt.want_files {
+				t.Errorf("Limiter() rates = %v, %v, want %v, %v", bytes_per_sec, files_per_sec, tt.want_bytes, tt.want_files)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ package expanders
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -56,8 +58,6 @@ func Fseq_to_object(files string) (reducers.File_seq, error) {
 	repl := strings.Replace(files, fs_listing[1], `@`, 1)
 	num_regex, _ := regexp.Compile(`([0-9]+|-|,)`)
 	num_results := num_regex.FindAllStringSubmatch(fs_listing[1], -1)
-	fp := len(num_results[0][0])
-	fp_string := fmt.Sprintf("%%0%vd", fp)
 
 	for index, reg_slice := range num_results {
 		if index == 0 {
@@ -71,11 +71,13 @@ func Fseq_to_object(files string) (reducers.File_seq, error) {
 			continue
 		}
 
+		//Frames inside a range are padded to the width of the start of the range
 		if reg_slice[0] == `-` {
 			start, _ := strconv.Atoi(num_results[index-1][0])
 			end, _ := strconv.Atoi(num_results[index+1][0])
+			fp := len(num_results[index-1][0])
 			for n := start + 1; n < end; n++ {
-				file_num[n] = fmt.Sprintf(fp_string, n)
+				file_num[n] = fmt.Sprintf("%0*d", fp, n)
 				file_list = append(file_list, n)
 			}
 			continue
@@ -107,3 +109,249 @@ func Fseq_expand(fs reducers.File_seq) ([]string, error) {
 
 	return files, nil
 }
+
+//Create a File_seq with every frame number shifted by offset, the gaps between
+//frames are kept.  Frame numbers are padded to pad digits, 0 for unpadded, or
+//keep the padding of the source when pad is -1.  When a padding is given no
+//frame number may be wider than it.
+func Fseq_renumber(fs reducers.File_seq, offset int, pad int) (reducers.File_seq, error) {
+	if !strings.Contains(fs.Base, `@`) {
+		return reducers.File_seq{}, errors.New(fs.F_seq + " is not a sequence of files, unable to renumber")
+	}
+	width := pad
+	if pad < 0 {
+		width = fs.Padding()
+	}
+
+	file_num := make(map[int]string)
+	for _, f := range fs.File_list {
+		n := f + offset
+		if n < 0 {
+			return reducers.File_seq{}, fmt.Errorf("Frame %d of %s would be renumbered to negative frame %d", f, fs.F_seq, n)
+		}
+		num := fmt.Sprintf("%0*d", width, n)
+		if pad > 0 && len(num) > pad {
+			return reducers.File_seq{}, fmt.Errorf("Frame %d of %s does not fit in a padding of %d", n, fs.F_seq, pad)
+		}
+		file_num[n] = num
+	}
+
+	return fseq_from_frames(fs.Base, file_num)
+}
+
+//Create a File_seq with only the frames of fs that are in frames, in order
+func Fseq_filter(fs reducers.File_seq, frames []int) (reducers.File_seq, error) {
+	wanted := make(map[int]bool)
+	for _, f := range frames {
+		wanted[f] = true
+	}
+
+	file_num := make(map[int]string)
+	for _, f := range fs.File_list {
+		if wanted[f] {
+			file_num[f] = fs.File_num[f]
+		}
+	}
+	if len(file_num) == 0 {
+		return reducers.File_seq{}, errors.New(fs.F_seq + " does not contain any of the frames given")
+	}
+	if !strings.Contains(fs.Base, `@`) {
+		return fs, nil
+	}
+	return fseq_from_frames(fs.Base, file_num)
+}
+
+//Create a File_seq from a base and its frame numbers.  A single frame is kept
+//in brackets ie: test.[005].jpg so the listing is still read as a sequence
+func fseq_from_frames(base string, file_num map[int]string) (reducers.File_seq, error) {
+	bases := map[string]map[int]string{base: file_num}
+	file_seqs, fseq_err := reducers.ReduceFileseq(bases)
+	if fseq_err != nil {
+		return reducers.File_seq{}, fseq_err
+	}
+	fseq := file_seqs[0]
+	if len(fseq.File_list) == 1 {
+		fseq.F_seq = strings.Replace(base, `@`, "["+fseq.File_num[fseq.File_list[0]]+"]", 1)
+	}
+	return fseq, nil
+}
+
+//Parse a frame range ie: "1001-1100,1200" into an ordered slice of frame
+//numbers, the same format used inside the brackets of a File_seq listing.
+//A range may have a step ie: "1001-1100x2" or "1001-1100:2" for every other
+//frame.  A frame range is a set of frames so 'x' is a step like FrameSet, not
+//the hold of a frame mapping, see package framemap
+func Frame_range(frames string) ([]int, error) {
+	var frame_list []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(strings.Trim(frames, "[] "), ",") {
+		step := 1
+		if i := strings.IndexAny(part, ":x"); i != -1 {
+			n, step_err := strconv.Atoi(strings.TrimSpace(part[i+1:]))
+			if step_err != nil || n < 1 {
+				return frame_list, errors.New(frames + " has an invalid step")
+			}
+			part = part[:i]
+			step = n
+		}
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		start, start_err := strconv.Atoi(bounds[0])
+		if start_err != nil {
+			return frame_list, errors.New(frames + " is not a valid frame range")
+		}
+		end := start
+		if len(bounds) == 2 {
+			n, end_err := strconv.Atoi(bounds[1])
+			if end_err != nil || n < start {
+				return frame_list, errors.New(frames + " is not a valid frame range")
+			}
+			end = n
+		}
+		for n := start; n <= end; n += step {
+			if !seen[n] {
+				seen[n] = true
+				frame_list = append(frame_list, n)
+			}
+		}
+	}
+	sort.Ints(frame_list)
+	return frame_list, nil
+}
+
+//Format frame numbers as a frame range ie: "1001-1100,1200", the reverse of
+//Frame_range
+func Frame_range_string(frames []int) string {
+	sorted := append([]int{}, frames...)
+	sort.Ints(sorted)
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] <= sorted[j]+1 {
+			j++
+		}
+		if sorted[j] == sorted[i] {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+//Regex for a field of a destination template ie: {name} or {frame:04d}
+var template_regex = regexp.MustCompile(`\{([A-Za-z0-9_]+)(?::([^}]*))?\}`)
+
+//Return the fields of a File_seq used by destination templates:
+//-dir is the directory of the sequence
+//-name is the file name before the frame number and separator
+//-sep is the separator before the frame number ie: '.', '_' or ' '
+//-ext is the extension after the frame number
+//-padding is the width of the frame numbers, 0 when unpadded
+func Fseq_fields(fs reducers.File_seq) (map[string]string, error) {
+	filename := filepath.Base(fs.Base)
+	at := strings.Index(filename, `@`)
+	if at < 0 {
+		return nil, errors.New(fs.F_seq + " is not a sequence of files")
+	}
+	name := filename[:at]
+	sep := ""
+	if len(name) > 0 && strings.ContainsAny(name[len(name)-1:], "._ ") {
+		sep = name[len(name)-1:]
+		name = name[:len(name)-1]
+	}
+	fields := map[string]string{
+		"dir":     filepath.Dir(fs.Base),
+		"name":    name,
+		"sep":     sep,
+		"ext":     strings.TrimPrefix(filename[at+1:], "."),
+		"padding": strconv.Itoa(fs.Padding()),
+	}
+	return fields, nil
+}
+
+//Return the named and numbered groups of a regex matched against the source
+//sequence path, for use as fields in destination templates ie: {shot} or {1}
+func Template_captures(fs reducers.File_seq, match string) (map[string]string, error) {
+	captures := make(map[string]string)
+	if match == "" {
+		return captures, nil
+	}
+	match_regex, reg_err := regexp.Compile(match)
+	if reg_err != nil {
+		return captures, reg_err
+	}
+	groups := match_regex.FindStringSubmatch(fs.Base)
+	if groups == nil {
+		return captures, errors.New(match + " does not match " + fs.F_seq)
+	}
+	for i, name := range match_regex.SubexpNames() {
+		captures[strconv.Itoa(i)] = groups[i]
+		if name != "" {
+			captures[name] = groups[i]
+		}
+	}
+	return captures, nil
+}
+
+//Create the destination File_seq of a source File_seq from a template such as
+///archive/{name}.{frame:04d}.{ext}, or from a directory the sequence is placed
+//in with the same file names.  Fields come from Fseq_fields and captures, the
+//frame field is required and takes an optional format ie: {frame:04d}
+func Fseq_template(fs reducers.File_seq, tmpl string, captures map[string]string) (reducers.File_seq, error) {
+	fields, field_err := Fseq_fields(fs)
+	if field_err != nil {
+		return reducers.File_seq{}, field_err
+	}
+
+	if strings.HasSuffix(tmpl, "/") || (!strings.Contains(tmpl, "{") && filesys.IsDir(tmpl)) {
+		return fseq_from_frames(filepath.Join(tmpl, filepath.Base(fs.Base)), fs.File_num)
+	}
+
+	frame_format := ""
+	frame_count := 0
+	var tmpl_err error
+	base := template_regex.ReplaceAllStringFunc(tmpl, func(field string) string {
+		groups := template_regex.FindStringSubmatch(field)
+		if groups[1] == "frame" {
+			frame_count++
+			frame_format = groups[2]
+			return `@`
+		}
+		if groups[2] != "" && tmpl_err == nil {
+			tmpl_err = errors.New("Only the frame field of a template may have a format, not " + field)
+		}
+		if value, ok := captures[groups[1]]; ok {
+			return value
+		}
+		if value, ok := fields[groups[1]]; ok {
+			return value
+		}
+		if tmpl_err == nil {
+			tmpl_err = errors.New(field + " is not a field of " + fs.F_seq + " or a capture of the match regex")
+		}
+		return field
+	})
+	if tmpl_err != nil {
+		return reducers.File_seq{}, tmpl_err
+	}
+	if frame_count != 1 {
+		return reducers.File_seq{}, errors.New("Template " + tmpl + " must contain {frame} once")
+	}
+	if !regexp.MustCompile(`[\.\_\ \/\\]@\.`).MatchString(base) {
+		return reducers.File_seq{}, errors.New("In template " + tmpl + " {frame} must follow a '.', '_', ' ' or '/' and be followed by '.ext'")
+	}
+	//A width without the 0 flag would pad frame numbers with spaces
+	if frame_format != "" && !regexp.MustCompile(`^0[0-9]+d$|^d$`).MatchString(frame_format) {
+		return reducers.File_seq{}, errors.New("Frame format " + frame_format + " is not like 04d or d")
+	}
+
+	file_num := make(map[int]string)
+	for _, f := range fs.File_list {
+		file_num[f] = fs.File_num[f]
+		if frame_format != "" {
+			file_num[f] = fmt.Sprintf("%"+frame_format, f)
+		}
+	}
+	return fseq_from_frames(base, file_num)
+}
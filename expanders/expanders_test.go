@@ -0,0 +1,173 @@
+package expanders
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+//Return the frames first to last by step
+func frameSpan(first int, last int, step int) []int {
+	var frames []int
+	for n := first; n <= last; n += step {
+		frames = append(frames, n)
+	}
+	return frames
+}
+
+func TestFrameRange(t *testing.T) {
+	tests := []struct {
+		frames  string
+		want    []int
+		wantErr bool
+	}{
+		{"1001", []int{1001}, false},
+		{"1001-1005", frameSpan(1001, 1005, 1), false},
+		{"[1001-1003,1010]", []int{1001, 1002, 1003, 1010}, false},
+		{"1010,1001-1003", []int{1001, 1002, 1003, 1010}, false},
+		{"1001-1005,1003-1007", frameSpan(1001, 1007, 1), false},
+		{"1001-1010:3", []int{1001, 1004, 1007, 1010}, false},
+		{"1001-1010x2", frameSpan(1001, 1009, 2), false},
+		{"1001-1100x25,1100", []int{1001, 1026, 1051, 1076, 1100}, false},
+		{"1010-1001", nil, true},
+		{"1001-1010x0", nil, true},
+		{"1001-1010:a", nil, true},
+		{"1001-", nil, true},
+		{"first-last", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.frames, func(t *testing.T) {
+			got, range_err := Frame_range(tt.frames)
+			if (range_err != nil) != tt.wantErr {
+				t.Fatalf("Frame_range(%q) error = %v, wantErr %v", tt.frames, range_err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Frame_range(%q) = %v, want %v", tt.frames, got, tt.want)
+			}
+		})
+	}
+}
+
+//Frames of a subrange that are not in the sequence are left out, a subrange
+//with none of its frames is an error
+func TestFseqFilter(t *testing.T) {
+	tests := []struct {
+		fs      string
+		frames  string
+		want    string
+		wantErr bool
+	}{
+		{"plate.[1001-1100].exr", "1010-1020", "plate.[1010-1020].exr", false},
+		{"plate.[1001-1100].exr", "1090-1200", "plate.[1090-1100].exr", false},
+		{"plate.[1001-1100].exr", "900-1005", "plate.[1001-1005].exr", false},
+		{"plate.[1001-1010,1020-1030].exr", "1005-1025", "plate.[1005-1010,1020-1025].exr", false},
+		{"plate.[1001-1100].exr", "1001-1030:10", "plate.[1001,1011,1021].exr", false},
+		{"plate.[1001-1100].exr", "1050", "plate.[1050].exr", false},
+		{"plate.[1001-1100].exr", "2000-2010", "", true},
+		{"plate.[1001-1010,1020-1030].exr", "1011-1019", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fs+" "+tt.frames, func(t *testing.T) {
+			fseq, fs_err := Fseq_to_object(tt.fs)
+			if fs_err != nil {
+				t.Fatal(fs_err)
+			}
+			frames, range_err := Frame_range(tt.frames)
+			if range_err != nil {
+				t.Fatal(range_err)
+			}
+			got, filter_err := Fseq_filter(fseq, frames)
+			if (filter_err != nil) != tt.wantErr {
+				t.Fatalf("Fseq_filter() error = %v, wantErr %v", filter_err, tt.wantErr)
+			}
+			if !tt.wantErr && got.F_seq != tt.want {
+				t.Errorf("Fseq_filter() = %q, want %q", got.F_seq, tt.want)
+			}
+		})
+	}
+}
+
+func TestFseqTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		match   string
+		want    string
+		wantErr bool
+	}{
+		{"fields", "/archive/{name}.{frame}.{ext}", "", "/archive/plate_v002.[1001-1003].exr", false},
+		{"sep and dir", "{dir}/out/{name}{sep}{frame}.{ext}", "", "/shots/sh010/out/plate_v002.[1001-1003].exr", false},
+		{"frame format", "/archive/{name}_{frame:06d}.{ext}", "", "/archive/plate_v002_[001001-001003].exr", false},
+		{"frame format d", "/archive/{name}.{frame:d}.{ext}", "", "/archive/plate_v002.[1001-1003].exr", false},
+		{"named capture", "/archive/{shot}/{name}.{frame}.{ext}", `/shots/(?P<shot>sh[0-9]+)/`, "/archive/sh010/plate_v002.[1001-1003].exr", false},
+		{"numbered capture", "/archive/{1}_{2}.{frame}.{ext}", `/(sh[0-9]+)/plate_(v[0-9]+)`, "/archive/sh010_v002.[1001-1003].exr", false},
+		{"directory", "/archive/", "", "/archive/plate_v002.[1001-1003].exr", false},
+		{"no frame", "/archive/{name}.{ext}", "", "", true},
+		{"frame twice", "/archive/{frame}.{name}.{frame}.{ext}", "", "", true},
+		{"frame without a separator", "/archive/{name}{frame}.{ext}", "", "", true},
+		{"frame without an extension", "/archive/{name}.{frame}", "", "", true},
+		{"unknown field", "/archive/{shot}.{frame}.{ext}", "", "", true},
+		{"format of another field", "/archive/{name:04d}.{frame}.{ext}", "", "", true},
+		{"frame width without the 0 flag", "/archive/{name}.{frame:6d}.{ext}", "", "", true},
+		{"frame format not a number", "/archive/{name}.{frame:04x}.{ext}", "", "", true},
+		{"match does not match", "/archive/{shot}.{frame}.{ext}", `/(?P<shot>sq[0-9]+)/`, "", true},
+		{"match is not a regex", "/archive/{name}.{frame}.{ext}", `(`, "", true},
+	}
+	fseq, fs_err := Fseq_to_object("/shots/sh010/plate_v002.[1001-1003].exr")
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captures, match_err := Template_captures(fseq, tt.match)
+			if match_err != nil {
+				if !tt.wantErr {
+					t.Fatalf("Template_captures() error = %v", match_err)
+				}
+				return
+			}
+			got, tmpl_err := Fseq_template(fseq, tt.tmpl, captures)
+			if (tmpl_err != nil) != tt.wantErr {
+				t.Fatalf("Fseq_template() error = %v, wantErr %v", tmpl_err, tt.wantErr)
+			}
+			if !tt.wantErr && got.F_seq != tt.want {
+				t.Errorf("Fseq_template() = %q, want %q", got.F_seq, tt.want)
+			}
+		})
+	}
+}
+
+//An existing directory is a destination directory without a trailing slash
+func TestFseqTemplateDir(t *testing.T) {
+	fseq, fs_err := Fseq_to_object("/shots/sh010/plate_v002.[1001-1003].exr")
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	dir := t.TempDir()
+	got, tmpl_err := Fseq_template(fseq, dir, nil)
+	if tmpl_err != nil {
+		t.Fatalf("Fseq_template() error = %v", tmpl_err)
+	}
+	if want := filepath.Join(dir, "plate_v002.[1001-1003].exr"); got.F_seq != want {
+		t.Errorf("Fseq_template() = %q, want %q", got.F_seq, want)
+	}
+}
+
+func TestTemplateCaptures(t *testing.T) {
+	fseq, fs_err := Fseq_to_object("/shots/sh010/plate_v002.[1001-1003].exr")
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	captures, match_err := Template_captures(fseq, `/(?P<shot>sh[0-9]+)/plate_(v[0-9]+)`)
+	if match_err != nil {
+		t.Fatal(match_err)
+	}
+	want := map[string]string{"0": "/sh010/plate_v002", "1": "sh010", "shot": "sh010", "2": "v002"}
+	if !reflect.DeepEqual(captures, want) {
+		t.Errorf("Template_captures() = %v, want %v", captures, want)
+	}
+	if captures, _ = Template_captures(fseq, ""); len(captures) != 0 {
+		t.Errorf("Template_captures() without a match = %v, want none", captures)
+	}
+}
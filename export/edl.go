@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/timecode"
+)
+
+//Timecode of the first event of an edit, the usual start of a reel
+const EdlRecordStart = "01:00:00:00"
+
+//Write sequences as the events of a CMX3600 EDL, one after the other from
+//EdlRecordStart.  Each event is the first to last frame of a sequence, the
+//source timecode of a frame is its frame number plus the tc_offsets of its
+//sequence, none for every sequence at its frame number
+func WriteEdl(w io.Writer, title string, seqs []reducers.File_seq, r timecode.Rate, tc_offsets []int) error {
+	if len(seqs) == 0 {
+		return errors.New("No sequences to write to an EDL")
+	}
+	if len(tc_offsets) != 0 && len(tc_offsets) != len(seqs) {
+		return errors.New("An EDL needs a timecode offset for every sequence")
+	}
+	record, tc_err := timecode.ToFrames(EdlRecordStart, r)
+	if tc_err != nil {
+		return tc_err
+	}
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "TITLE: %s\n", title)
+	if r.Drop {
+		fmt.Fprintf(writer, "FCM: DROP FRAME\n")
+	} else {
+		fmt.Fprintf(writer, "FCM: NON-DROP FRAME\n")
+	}
+	for i, fs := range seqs {
+		if len(fs.File_list) == 0 {
+			return errors.New(fs.F_seq + " has no frames")
+		}
+		first, last := fs.File_list[0], fs.File_list[len(fs.File_list)-1]
+		length := last - first + 1
+		source := first
+		if len(tc_offsets) != 0 {
+			source += tc_offsets[i]
+		}
+		if source < 0 {
+			return fmt.Errorf("Frame %d of %s is before 00:00:00:00", first, fs.F_seq)
+		}
+		//Out points are the frame after the last frame of an event
+		fmt.Fprintf(writer, "\n%03d  %-8s V     C        %s %s %s %s\n", i+1, "AX",
+			timecode.FromFrames(source, r), timecode.FromFrames(source+length, r),
+			timecode.FromFrames(record, r), timecode.FromFrames(record+length, r))
+		fmt.Fprintf(writer, "* FROM CLIP NAME: %s\n", filepath.Base(fs.F_seq))
+		fmt.Fprintf(writer, "* SOURCE FILE: %s\n", fs.F_seq)
+		record += length
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,136 @@
+//Package export writes sequences in the formats other tools read them in, ie:
+//the input arguments of ffmpeg, CMX3600 EDLs and OpenTimelineIO timelines.
+//OpenTimelineIO image sequence references may be read back as File_seqs
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/timecode"
+)
+
+//Return a frame rate the way ffmpeg takes it, rates like 23.976 are given
+//exactly ie: 24000/1001
+func FfmpegRate(r timecode.Rate) string {
+	if float64(r.Base) == r.Fps {
+		return strconv.Itoa(r.Base)
+	}
+	if math.Abs(r.Fps-float64(r.Base)*1000/1001) < 0.001 {
+		return fmt.Sprintf("%d/1001", r.Base*1000)
+	}
+	return strconv.FormatFloat(r.Fps, 'f', -1, 64)
+}
+
+//Return the image2 pattern of a sequence ie: plate.%04d.exr, a '%' in the path
+//is escaped as '%%'
+func FfmpegPattern(fs reducers.File_seq) (string, error) {
+	if !strings.Contains(fs.Base, `@`) {
+		return "", errors.New(fs.F_seq + " is not a sequence of files")
+	}
+	frame := "%d"
+	if pad := fs.Padding(); pad > 0 {
+		frame = fmt.Sprintf("%%0%dd", pad)
+	}
+	return strings.Replace(strings.ReplaceAll(fs.Base, "%", "%%"), `@`, frame, 1), nil
+}
+
+//Return the ffmpeg image2 input arguments of a sequence ie:
+//-framerate 24 -start_number 1001 -i plate.%04d.exr
+//image2 stops at the first missing frame, so a sequence with gaps is an error,
+//see FfmpegConcat
+func FfmpegArgs(fs reducers.File_seq, r timecode.Rate) ([]string, error) {
+	if HasGaps(fs) {
+		return nil, errors.New(fs.F_seq + " has gaps, use a concat file")
+	}
+	pattern, pattern_err := FfmpegPattern(fs)
+	if pattern_err != nil {
+		return nil, pattern_err
+	}
+	args := []string{
+		"-framerate", FfmpegRate(r),
+		"-start_number", strconv.Itoa(fs.File_list[0]),
+		"-i", pattern,
+	}
+	return args, nil
+}
+
+//Write an ffmpeg concat demuxer file of a sequence to pth and return the input
+//arguments that read it.  Each frame is held until the next frame, so a gap
+//holds the frame before it and the movie keeps the timing of the frame numbers.
+//An existing file at pth is only overwritten with force
+func FfmpegConcat(fs reducers.File_seq, r timecode.Rate, pth string, force bool) ([]string, error) {
+	if len(fs.File_list) == 0 {
+		return nil, errors.New(fs.F_seq + " has no frames")
+	}
+	if _, stat_err := os.Lstat(pth); stat_err == nil && !force {
+		return nil, errors.New(pth + " already exists\nUse -f to overwrite it")
+	}
+	f, create_err := os.Create(pth)
+	if create_err != nil {
+		return nil, create_err
+	}
+	writer := bufio.NewWriter(f)
+	fmt.Fprintf(writer, "ffconcat version 1.0\n")
+	var last string
+	for i, frame := range fs.File_list {
+		abs, abs_err := filepath.Abs(strings.Replace(fs.Base, `@`, fs.File_num[frame], 1))
+		if abs_err != nil {
+			f.Close()
+			return nil, abs_err
+		}
+		held := 1
+		if i+1 < len(fs.File_list) {
+			held = fs.File_list[i+1] - frame
+		}
+		last = concatQuote(abs)
+		fmt.Fprintf(writer, "file %s\nduration %s\n", last, strconv.FormatFloat(float64(held)/r.Fps, 'f', 6, 64))
+	}
+	//The duration of the last file is only used when a file follows it
+	fmt.Fprintf(writer, "file %s\n", last)
+	if flush_err := writer.Flush(); flush_err != nil {
+		f.Close()
+		return nil, flush_err
+	}
+	if close_err := f.Close(); close_err != nil {
+		return nil, close_err
+	}
+	return []string{"-f", "concat", "-safe", "0", "-i", pth}, nil
+}
+
+//Test if a sequence is missing any frames between its first and last frame
+func HasGaps(fs reducers.File_seq) bool {
+	if len(fs.File_list) == 0 {
+		return false
+	}
+	return fs.File_list[len(fs.File_list)-1]-fs.File_list[0]+1 != len(fs.File_list)
+}
+
+//Quote a path for a concat file, a quote inside it is written as '\''
+func concatQuote(pth string) string {
+	return "'" + strings.ReplaceAll(pth, "'", `'\''`) + "'"
+}
+
+//Regex of the arguments that do not need quotes in a shell
+var shell_safe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+//Join arguments into a command line for a shell, quoting the ones that need it
+func ShellJoin(args []string) string {
+	var quoted []string
+	for _, x := range args {
+		if shell_safe.MatchString(x) {
+			quoted = append(quoted, x)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(x, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
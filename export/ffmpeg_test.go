@@ -0,0 +1,133 @@
+package export
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/timecode"
+)
+
+func fseq(t *testing.T, listing string) reducers.File_seq {
+	fs, fs_err := expanders.Fseq_to_object(listing)
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	return fs
+}
+
+func rate(t *testing.T, fps string) timecode.Rate {
+	r, rate_err := timecode.ParseRate(fps)
+	if rate_err != nil {
+		t.Fatal(rate_err)
+	}
+	return r
+}
+
+func TestFfmpegRate(t *testing.T) {
+	for fps, want := range map[string]string{"24": "24", "23.976": "24000/1001", "29.97df": "30000/1001", "12.5": "12.5"} {
+		if got := FfmpegRate(rate(t, fps)); got != want {
+			t.Errorf("FfmpegRate(%s) = %q, want %q", fps, got, want)
+		}
+	}
+}
+
+func TestFfmpegArgs(t *testing.T) {
+	tests := []struct {
+		listing string
+		fps     string
+		want    []string
+		wantErr bool
+	}{
+		{"/shots/plate.[1001-1100].exr", "24", []string{"-framerate", "24", "-start_number", "1001", "-i", "/shots/plate.%04d.exr"}, false},
+		{"/shots/plate.[0990-1010].exr", "24", []string{"-framerate", "24", "-start_number", "990", "-i", "/shots/plate.%04d.exr"}, false},
+		{"/shots/plate.[1-100].exr", "23.976", []string{"-framerate", "24000/1001", "-start_number", "1", "-i", "/shots/plate.%d.exr"}, false},
+		{"/shots/100%/plate.[0001-0010].exr", "25", []string{"-framerate", "25", "-start_number", "1", "-i", "/shots/100%%/plate.%04d.exr"}, false},
+		{"/shots/plate.[1001-1010,1020-1030].exr", "24", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.listing, func(t *testing.T) {
+			got, args_err := FfmpegArgs(fseq(t, tt.listing), rate(t, tt.fps))
+			if (args_err != nil) != tt.wantErr {
+				t.Fatalf("FfmpegArgs() error = %v, wantErr %v", args_err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FfmpegArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFfmpegConcat(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing string
+		want    string
+	}{
+		{"without gaps", "/shots/plate.[1001-1003].exr",
+			"ffconcat version 1.0\n" +
+				"file '/shots/plate.1001.exr'\nduration 0.040000\n" +
+				"file '/shots/plate.1002.exr'\nduration 0.040000\n" +
+				"file '/shots/plate.1003.exr'\nduration 0.040000\n" +
+				"file '/shots/plate.1003.exr'\n"},
+		{"gaps hold the frame before", "/shots/plate.[1001,1004-1005].exr",
+			"ffconcat version 1.0\n" +
+				"file '/shots/plate.1001.exr'\nduration 0.120000\n" +
+				"file '/shots/plate.1004.exr'\nduration 0.040000\n" +
+				"file '/shots/plate.1005.exr'\nduration 0.040000\n" +
+				"file '/shots/plate.1005.exr'\n"},
+		{"quotes", "/shots/bob's/plate.[1-2].exr",
+			"ffconcat version 1.0\n" +
+				`file '/shots/bob'\''s/plate.1.exr'` + "\nduration 0.040000\n" +
+				`file '/shots/bob'\''s/plate.2.exr'` + "\nduration 0.040000\n" +
+				`file '/shots/bob'\''s/plate.2.exr'` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "plate.ffconcat")
+			args, concat_err := FfmpegConcat(fseq(t, tt.listing), rate(t, "25"), pth, false)
+			if concat_err != nil {
+				t.Fatalf("FfmpegConcat() error = %v", concat_err)
+			}
+			if want := []string{"-f", "concat", "-safe", "0", "-i", pth}; !reflect.DeepEqual(args, want) {
+				t.Errorf("FfmpegConcat() = %q, want %q", args, want)
+			}
+			data, read_err := ioutil.ReadFile(pth)
+			if read_err != nil {
+				t.Fatal(read_err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("FfmpegConcat() wrote\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+//An existing concat file is only overwritten with force
+func TestFfmpegConcatExisting(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "plate.ffconcat")
+	if write_err := ioutil.WriteFile(pth, []byte("keep"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	fs := fseq(t, "/shots/plate.[1001-1003].exr")
+	if _, concat_err := FfmpegConcat(fs, rate(t, "24"), pth, false); concat_err == nil {
+		t.Error("FfmpegConcat() over an existing file without force did not fail")
+	}
+	if data, _ := ioutil.ReadFile(pth); string(data) != "keep" {
+		t.Errorf("FfmpegConcat() without force changed the existing file to %q", data)
+	}
+	if _, concat_err := FfmpegConcat(fs, rate(t, "24"), pth, true); concat_err != nil {
+		t.Errorf("FfmpegConcat() with force error = %v", concat_err)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	got := ShellJoin([]string{"-i", "/shots/plate.%04d.exr", "/shots/bob's plate.mov"})
+	want := `-i /shots/plate.%04d.exr '/shots/bob'\''s plate.mov'`
+	if got != want {
+		t.Errorf("ShellJoin() = %q, want %q", got, want)
+	}
+}
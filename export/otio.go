@@ -0,0 +1,269 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/timecode"
+)
+
+//What an OpenTimelineIO player shows for the missing frames of a sequence
+var OtioMissingPolicies = []string{"error", "hold", "black"}
+
+//Structs for the json of an OpenTimelineIO timeline with one video track
+type otioTimeline struct {
+	Schema   string                 `json:"OTIO_SCHEMA"`
+	Name     string                 `json:"name"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Tracks   otioStack              `json:"tracks"`
+}
+
+type otioStack struct {
+	Schema   string                 `json:"OTIO_SCHEMA"`
+	Name     string                 `json:"name"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Children []otioTrack            `json:"children"`
+}
+
+type otioTrack struct {
+	Schema   string                 `json:"OTIO_SCHEMA"`
+	Name     string                 `json:"name"`
+	Kind     string                 `json:"kind"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Children []otioClip             `json:"children"`
+}
+
+type otioClip struct {
+	Schema          string                     `json:"OTIO_SCHEMA"`
+	Name            string                     `json:"name"`
+	Metadata        map[string]interface{}     `json:"metadata"`
+	SourceRange     otioTimeRange              `json:"source_range"`
+	MediaReferences map[string]otioImageSeqRef `json:"media_references"`
+	ActiveReference string                     `json:"active_media_reference_key"`
+	Enabled         bool                       `json:"enabled"`
+}
+
+type otioImageSeqRef struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	Name           string                 `json:"name"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	AvailableRange otioTimeRange          `json:"available_range"`
+	TargetUrlBase  string                 `json:"target_url_base"`
+	NamePrefix     string                 `json:"name_prefix"`
+	NameSuffix     string                 `json:"name_suffix"`
+	StartFrame     int                    `json:"start_frame"`
+	FrameStep      int                    `json:"frame_step"`
+	Rate           float64                `json:"rate"`
+	Padding        int                    `json:"frame_zero_padding"`
+	MissingPolicy  string                 `json:"missing_frame_policy"`
+}
+
+type otioTimeRange struct {
+	Schema    string           `json:"OTIO_SCHEMA"`
+	StartTime otioRationalTime `json:"start_time"`
+	Duration  otioRationalTime `json:"duration"`
+}
+
+type otioRationalTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+//Return an OpenTimelineIO time range of frames at rate
+func otioRange(start int, duration int, rate float64) otioTimeRange {
+	return otioTimeRange{
+		Schema:    "TimeRange.1",
+		StartTime: otioRationalTime{Schema: "RationalTime.1", Rate: rate, Value: float64(start)},
+		Duration:  otioRationalTime{Schema: "RationalTime.1", Rate: rate, Value: float64(duration)},
+	}
+}
+
+//Write sequences as the clips of an OpenTimelineIO timeline, one after the
+//other.  Each clip has an ImageSequenceReference of the first to last frame of
+//its sequence, missing is what is shown for the missing frames: error, hold or
+//black
+func WriteOtio(w io.Writer, title string, seqs []reducers.File_seq, r timecode.Rate, missing string) error {
+	if len(seqs) == 0 {
+		return errors.New("No sequences to write to a timeline")
+	}
+	valid := false
+	for _, x := range OtioMissingPolicies {
+		valid = valid || x == missing
+	}
+	if !valid {
+		return fmt.Errorf("Missing frame policy %s must be one of %s", missing, strings.Join(OtioMissingPolicies, ", "))
+	}
+	track := otioTrack{Schema: "Track.1", Name: "V1", Kind: "Video", Metadata: map[string]interface{}{}, Children: []otioClip{}}
+	for _, fs := range seqs {
+		fields, field_err := expanders.Fseq_fields(fs)
+		if field_err != nil {
+			return field_err
+		}
+		if len(fs.File_list) == 0 {
+			return errors.New(fs.F_seq + " has no frames")
+		}
+		dir, abs_err := filepath.Abs(fields["dir"])
+		if abs_err != nil {
+			return abs_err
+		}
+		first, last := fs.File_list[0], fs.File_list[len(fs.File_list)-1]
+		frames := otioRange(first, last-first+1, r.Fps)
+		filename := filepath.Base(fs.Base)
+		at := strings.Index(filename, `@`)
+		ref := otioImageSeqRef{
+			Schema:         "ImageSequenceReference.1",
+			Metadata:       map[string]interface{}{},
+			AvailableRange: frames,
+			TargetUrlBase:  (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir) + "/"}).String(),
+			NamePrefix:     filename[:at],
+			NameSuffix:     filename[at+1:],
+			StartFrame:     first,
+			FrameStep:      1,
+			Rate:           r.Fps,
+			Padding:        fs.Padding(),
+			MissingPolicy:  missing,
+		}
+		track.Children = append(track.Children, otioClip{
+			Schema:          "Clip.2",
+			Name:            filepath.Base(fs.F_seq),
+			Metadata:        map[string]interface{}{},
+			SourceRange:     frames,
+			MediaReferences: map[string]otioImageSeqRef{"DEFAULT_MEDIA": ref},
+			ActiveReference: "DEFAULT_MEDIA",
+			Enabled:         true,
+		})
+	}
+	timeline := otioTimeline{
+		Schema:   "Timeline.1",
+		Name:     title,
+		Metadata: map[string]interface{}{},
+		Tracks: otioStack{
+			Schema:   "Stack.1",
+			Name:     "tracks",
+			Metadata: map[string]interface{}{},
+			Children: []otioTrack{track},
+		},
+	}
+	out, json_err := json.MarshalIndent(timeline, "", "    ")
+	if json_err != nil {
+		return json_err
+	}
+	_, write_err := w.Write(append(out, '\n'))
+	return write_err
+}
+
+//Read the ImageSequenceReferences of an OpenTimelineIO file as File_seqs, in
+//the order they are in the file.  Each has the frames of its available range
+func ReadOtio(pth string) ([]reducers.File_seq, error) {
+	var seqs []reducers.File_seq
+	data, read_err := ioutil.ReadFile(pth)
+	if read_err != nil {
+		return seqs, read_err
+	}
+	var doc interface{}
+	if json_err := json.Unmarshal(data, &doc); json_err != nil {
+		return seqs, fmt.Errorf("%s is not an OpenTimelineIO file - %v", pth, json_err)
+	}
+	var refs []map[string]interface{}
+	findImageSeqRefs(doc, &refs)
+	if len(refs) == 0 {
+		return seqs, errors.New(pth + " has no image sequence references")
+	}
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		fs, ref_err := otioRefSeq(ref)
+		if ref_err != nil {
+			return seqs, fmt.Errorf("%s - %v", pth, ref_err)
+		}
+		if !seen[fs.F_seq] {
+			seen[fs.F_seq] = true
+			seqs = append(seqs, fs)
+		}
+	}
+	return seqs, nil
+}
+
+//Add every object with an ImageSequenceReference schema in v to refs
+func findImageSeqRefs(v interface{}, refs *[]map[string]interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if schema, _ := x["OTIO_SCHEMA"].(string); strings.HasPrefix(schema, "ImageSequenceReference.") {
+			*refs = append(*refs, x)
+			return
+		}
+		//Only the active media reference of a clip is used
+		if media, ok := x["media_references"].(map[string]interface{}); ok {
+			if active, ok := x["active_media_reference_key"].(string); ok {
+				findImageSeqRefs(media[active], refs)
+				return
+			}
+		}
+		//Keys are sorted so the order does not change between runs, the
+		//children of a track keep their order
+		var keys []string
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			findImageSeqRefs(x[key], refs)
+		}
+	case []interface{}:
+		for _, child := range x {
+			findImageSeqRefs(child, refs)
+		}
+	}
+}
+
+//Return the File_seq of an ImageSequenceReference
+func otioRefSeq(ref map[string]interface{}) (reducers.File_seq, error) {
+	base_url, _ := ref["target_url_base"].(string)
+	prefix, _ := ref["name_prefix"].(string)
+	suffix, _ := ref["name_suffix"].(string)
+	start := jsonInt(ref["start_frame"], 1)
+	step := jsonInt(ref["frame_step"], 1)
+	pad := jsonInt(ref["frame_zero_padding"], 0)
+	duration := 0
+	if available, ok := ref["available_range"].(map[string]interface{}); ok {
+		if d, ok := available["duration"].(map[string]interface{}); ok {
+			duration = jsonInt(d["value"], 0)
+		}
+	}
+	if duration < 1 || step < 1 {
+		return reducers.File_seq{}, errors.New("image sequence reference " + prefix + suffix + " has no frames")
+	}
+	dir := base_url
+	if u, url_err := url.Parse(base_url); url_err == nil && (u.Scheme == "file" || u.Scheme == "") {
+		dir = u.Path
+	}
+	base := filepath.Join(filepath.FromSlash(dir), prefix+`@`+suffix)
+
+	//The available range counts the frames of the reference, every step
+	file_num := make(map[int]string)
+	for i := 0; i < duration; i += step {
+		file_num[start+i] = fmt.Sprintf("%0*d", pad, start+i)
+	}
+	file_seqs, fseq_err := reducers.ReduceFileseq(map[string]map[int]string{base: file_num})
+	if fseq_err != nil {
+		return reducers.File_seq{}, fseq_err
+	}
+	return file_seqs[0], nil
+}
+
+//Return a json number as an int, or def when it is not a number
+func jsonInt(v interface{}, def int) int {
+	if n, ok := v.(float64); ok {
+		return int(n)
+	}
+	return def
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/timecode"
+)
+
+func TestOtioRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		listings []string
+		fps      string
+		want     []string
+	}{
+		{"padded", []string{"plate.[1001-1100].exr"}, "24", []string{"plate.[1001-1100].exr"}},
+		{"unpadded", []string{"shot_v2.[1-12].dpx"}, "25", []string{"shot_v2.[1-12].dpx"}},
+		{"ntsc", []string{"comp.[0086-0100].exr"}, "23.976", []string{"comp.[0086-0100].exr"}},
+		{"gaps are held", []string{"plate.[1001-1010,1020].exr"}, "24", []string{"plate.[1001-1020].exr"}},
+		{"space in the path", []string{"cut 2/plate.[101-110].exr"}, "24", []string{"cut 2/plate.[101-110].exr"}},
+		{"in order", []string{"sh020/a.[1001-1048].exr", "sh010/b.[1001-1100].exr"}, "24", []string{"sh020/a.[1001-1048].exr", "sh010/b.[1001-1100].exr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			r, rate_err := timecode.ParseRate(tt.fps)
+			if rate_err != nil {
+				t.Fatal(rate_err)
+			}
+			var seqs []reducers.File_seq
+			for _, x := range tt.listings {
+				fs, fs_err := expanders.Fseq_to_object(filepath.Join(dir, x))
+				if fs_err != nil {
+					t.Fatal(fs_err)
+				}
+				seqs = append(seqs, fs)
+			}
+			var out bytes.Buffer
+			if write_err := WriteOtio(&out, "fileseq", seqs, r, "hold"); write_err != nil {
+				t.Fatalf("WriteOtio() error = %v", write_err)
+			}
+			pth := filepath.Join(dir, "cut.otio")
+			if write_err := ioutil.WriteFile(pth, out.Bytes(), 0644); write_err != nil {
+				t.Fatal(write_err)
+			}
+			read, read_err := ReadOtio(pth)
+			if read_err != nil {
+				t.Fatalf("ReadOtio() error = %v", read_err)
+			}
+			if len(read) != len(tt.want) {
+				t.Fatalf("ReadOtio() = %d sequences, want %d", len(read), len(tt.want))
+			}
+			for i, fs := range read {
+				if want := filepath.Join(dir, tt.want[i]); fs.F_seq != want {
+					t.Errorf("ReadOtio() sequence %d = %s, want %s", i, fs.F_seq, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteOtioMissingPolicy(t *testing.T) {
+	fs, fs_err := expanders.Fseq_to_object("plate.[1001-1100].exr")
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	r, _ := timecode.ParseRate("24")
+	var out bytes.Buffer
+	if write_err := WriteOtio(&out, "fileseq", []reducers.File_seq{fs}, r, "skip"); write_err == nil {
+		t.Error("WriteOtio() with a missing frame policy of skip did not fail")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 //Return the current directory
@@ -30,8 +31,13 @@ func Recurse(curdir string, verbose bool) ([]string, error) {
 		return fileList, oserr
 	}
 	err := filepath.Walk(curdir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if !f.IsDir() {
 			fileList = append(fileList, path)
+		} else if path != curdir && IsTrashDir(f.Name()) {
+			return filepath.SkipDir
 		} else {
 			dirCount++
 			if verbose {
@@ -53,9 +59,22 @@ func IsFile(file string) (bool, error) {
 	if open_err != nil {
 		return false, open_err
 	}
+	defer f.Close()
 	fi, stat_err := f.Stat()
 	if stat_err != nil {
 		return false, stat_err
 	}
 	return fi.Mode().IsRegular(), nil
 }
+
+//Test if string is a directory
+func IsDir(pth string) bool {
+	fi, stat_err := os.Stat(pth)
+	return stat_err == nil && fi.IsDir()
+}
+
+//Test if a directory name is a volume trash directory (.Trash or .Trash-$uid),
+//these are skipped when recursing
+func IsTrashDir(name string) bool {
+	return name == ".Trash" || strings.HasPrefix(name, ".Trash-")
+}
@@ -0,0 +1,113 @@
+//Package framemap parses frame mapping expressions used to retime a sequence
+//when copying it.  An expression lists the source frames in the order they
+//should be written to the destination, so frames may be reordered, repeated or
+//skipped.  Terms are separated by commas:
+//
+//	1001           a single source frame
+//	1001-1100      a range of source frames, 1100-1001 is the range reversed
+//	1001-1100:2    every other frame of a range
+//	1001x24        hold a frame for 24 frames
+//	1001-1100x2    every frame of a range twice (on twos)
+//	all            every source frame in order
+//	reverse        every source frame reversed
+//
+//The step ':' and repeat 'x' may be added to any term, ie: reverse:2x2
+package framemap
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Regex for a single term, groups are start, end, step and repeat
+var term_regex = regexp.MustCompile(`^(all|reverse|[0-9]+)(?:-([0-9]+))?(?::([0-9]+))?(?:x([0-9]+))?$`)
+
+//Parse a mapping expression against the frames of the source sequence and
+//return the source frame for every destination frame in order.  Every frame
+//used by the expression must exist in the source.
+func Parse(expr string, source []int) ([]int, error) {
+	var mapped []int
+	exists := make(map[int]bool)
+	for _, f := range source {
+		exists[f] = true
+	}
+
+	for _, term := range strings.Split(strings.ToLower(strings.Replace(expr, " ", "", -1)), ",") {
+		groups := term_regex.FindStringSubmatch(term)
+		if len(groups) == 0 {
+			return mapped, fmt.Errorf("'%s' is not a valid frame mapping term", term)
+		}
+
+		var frames []int
+		switch groups[1] {
+		case "all", "reverse":
+			if groups[2] != "" {
+				return mapped, fmt.Errorf("'%s' can not be given a range", term)
+			}
+			frames = append(frames, source...)
+			if groups[1] == "reverse" {
+				for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+					frames[i], frames[j] = frames[j], frames[i]
+				}
+			}
+		default:
+			start, _ := strconv.Atoi(groups[1])
+			end := start
+			if groups[2] != "" {
+				end, _ = strconv.Atoi(groups[2])
+			}
+			dir := 1
+			if end < start {
+				dir = -1
+			}
+			//Only frames that exist in the source are taken from a range
+			//so ranges over sequences with gaps work as expected
+			for n := start; n != end+dir; n += dir {
+				if exists[n] {
+					frames = append(frames, n)
+				}
+			}
+			if groups[2] == "" && !exists[start] {
+				return mapped, fmt.Errorf("Frame %d is not in the source sequence", start)
+			}
+			if len(frames) == 0 {
+				return mapped, fmt.Errorf("'%s' does not contain any source frames", term)
+			}
+		}
+
+		step, step_err := termNumber(groups[3])
+		if step_err != nil {
+			return mapped, fmt.Errorf("'%s' has an invalid step", term)
+		}
+		repeat, repeat_err := termNumber(groups[4])
+		if repeat_err != nil {
+			return mapped, fmt.Errorf("'%s' has an invalid repeat", term)
+		}
+
+		for i := 0; i < len(frames); i += step {
+			for r := 0; r < repeat; r++ {
+				mapped = append(mapped, frames[i])
+			}
+		}
+	}
+
+	if len(mapped) == 0 {
+		return mapped, errors.New("Frame mapping '" + expr + "' does not contain any frames")
+	}
+	return mapped, nil
+}
+
+//Return the number of a step or repeat, defaulting to 1 when not given
+func termNumber(num string) (int, error) {
+	if num == "" {
+		return 1, nil
+	}
+	n, conv_err := strconv.Atoi(num)
+	if conv_err != nil || n < 1 {
+		return 0, errors.New(num + " must be at least 1")
+	}
+	return n, nil
+}
@@ -0,0 +1,97 @@
+package framemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+//Return the frames first to last, reversed when last is before first
+func span(first int, last int) []int {
+	var frames []int
+	for n := first; n != last; {
+		frames = append(frames, n)
+		if last > first {
+			n++
+		} else {
+			n--
+		}
+	}
+	return append(frames, last)
+}
+
+//Return each frame repeated times
+func hold(frames []int, times int) []int {
+	var held []int
+	for _, f := range frames {
+		for i := 0; i < times; i++ {
+			held = append(held, f)
+		}
+	}
+	return held
+}
+
+func TestParse(t *testing.T) {
+	full := span(1001, 1010)
+	gaps := []int{1001, 1002, 1005, 1006}
+	tests := []struct {
+		name   string
+		expr   string
+		source []int
+		want   []int
+	}{
+		{"frame", "1005", full, []int{1005}},
+		{"range", "1001-1004", full, span(1001, 1004)},
+		{"reversed range", "1004-1001", full, span(1004, 1001)},
+		{"step", "1001-1010:3", full, []int{1001, 1004, 1007, 1010}},
+		{"hold", "1001x4", full, hold([]int{1001}, 4)},
+		{"on twos", "1001-1003x2", full, hold(span(1001, 1003), 2)},
+		{"all", "all", full, full},
+		{"reverse", "reverse", full, span(1010, 1001)},
+		{"reverse step and hold", "reverse:3x2", full, hold([]int{1010, 1007, 1004, 1001}, 2)},
+		{"terms in order", "1005-1006,1001x2,all:5", full, []int{1005, 1006, 1001, 1001, 1001, 1006}},
+		{"spaces and case", " 1001 , ALL:9 ", full, []int{1001, 1001, 1010}},
+		{"range over gaps", "1001-1006", gaps, gaps},
+		{"reversed range over gaps", "1006-1001", gaps, []int{1006, 1005, 1002, 1001}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, parse_err := Parse(tt.expr, tt.source)
+			if parse_err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, parse_err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	full := span(1001, 1010)
+	tests := []struct {
+		name   string
+		expr   string
+		source []int
+	}{
+		{"empty", "", full},
+		{"empty term", "1001,,1002", full},
+		{"not a term", "first", full},
+		{"open range", "1001-", full},
+		{"negative frame", "-1001", full},
+		{"step of 0", "1001-1010:0", full},
+		{"hold of 0", "1001x0", full},
+		{"hold before step", "1001-1010x2:2", full},
+		{"all with a range", "all-1005", full},
+		{"frame not in the source", "2001", full},
+		{"range not in the source", "2001-2010", full},
+		{"range inside a gap", "1003-1004", []int{1001, 1002, 1005, 1006}},
+		{"frame inside a gap", "1003", []int{1001, 1002, 1005, 1006}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, parse_err := Parse(tt.expr, tt.source); parse_err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.expr, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package imageinfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+//Offsets of the fields of a DPX header
+const (
+	dpx_elements   = 770
+	dpx_width      = 772
+	dpx_height     = 776
+	dpx_descriptor = 800
+	dpx_bitsize    = 803
+	dpx_encoding   = 806
+	dpx_film_rate  = 1724
+	dpx_timecode   = 1920
+	dpx_tv_rate    = 1940
+	dpx_header_len = 2048
+)
+
+//Read the header of a DPX file, the magic number gives the byte order.  A file
+//shorter than the header is an error
+func readDpx(f *os.File) (Header, error) {
+	h := Header{Format: "dpx"}
+	buf := make([]byte, dpx_header_len)
+	if _, read_err := f.ReadAt(buf, 0); read_err == io.EOF {
+		return h, errors.New("the header is truncated")
+	} else if read_err != nil {
+		return h, read_err
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if string(buf[:4]) == "XPDS" {
+		order = binary.LittleEndian
+	}
+	if order.Uint16(buf[dpx_elements:]) == 0 {
+		return h, fmt.Errorf("no image elements")
+	}
+	h.Width = int(order.Uint32(buf[dpx_width:]))
+	h.Height = int(order.Uint32(buf[dpx_height:]))
+	h.Channels = dpxChannels(buf[dpx_descriptor])
+	h.BitDepth = int(buf[dpx_bitsize])
+	h.Compression = "none"
+	if order.Uint16(buf[dpx_encoding:]) == 1 {
+		h.Compression = "rle"
+	}
+	h.Timecode = formatTimecode(order.Uint32(buf[dpx_timecode:]))
+
+	//The frame rate is in the television header, or the film header
+	for _, offset := range []int{dpx_tv_rate, dpx_film_rate} {
+		bits := order.Uint32(buf[offset:])
+		rate := float64(math.Float32frombits(bits))
+		if bits != 0xffffffff && rate > 0 && rate < 1000 {
+			h.FrameRate = math.Round(rate*1000) / 1000
+			break
+		}
+	}
+	return h, nil
+}
+
+//Return the channel names of the descriptor of a DPX image element
+func dpxChannels(descriptor byte) []string {
+	switch descriptor {
+	case 1:
+		return []string{"R"}
+	case 2:
+		return []string{"G"}
+	case 3:
+		return []string{"B"}
+	case 4:
+		return []string{"A"}
+	case 6:
+		return []string{"Y"}
+	case 50:
+		return []string{"R", "G", "B"}
+	case 51:
+		return []string{"R", "G", "B", "A"}
+	case 52:
+		return []string{"A", "B", "G", "R"}
+	case 100:
+		return []string{"Cb", "Y", "Cr", "Y"}
+	case 101:
+		return []string{"Cb", "Y", "A", "Cr", "Y", "A"}
+	case 102:
+		return []string{"Cb", "Y", "Cr"}
+	case 103:
+		return []string{"Cb", "Y", "Cr", "A"}
+	}
+	return []string{fmt.Sprintf("descriptor %d", descriptor)}
+}
@@ -0,0 +1,104 @@
+package imageinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+//Names of the compressions of an EXR file by number
+var exr_compressions = []string{"none", "rle", "zips", "zip", "piz", "pxr24", "b44", "b44a", "dwaa", "dwab", "htj2k256", "htj2k32"}
+
+//Read the header of an EXR file after its magic number.  Only the first part
+//of a multi-part file is read
+func readExr(f *os.File) (Header, error) {
+	h := Header{Format: "exr"}
+	r := bufio.NewReader(io.NewSectionReader(f, 8, 1<<24))
+	for {
+		name, name_err := readString(r)
+		if name_err != nil {
+			return h, name_err
+		}
+		if name == "" {
+			break
+		}
+		attr_type, type_err := readString(r)
+		if type_err != nil {
+			return h, type_err
+		}
+		var size int32
+		if size_err := binary.Read(r, binary.LittleEndian, &size); size_err != nil {
+			return h, size_err
+		}
+		if size < 0 || size > 1<<24 {
+			return h, fmt.Errorf("attribute %s has an invalid size", name)
+		}
+		value := make([]byte, size)
+		if _, read_err := io.ReadFull(r, value); read_err != nil {
+			return h, read_err
+		}
+		switch {
+		case name == "channels" && attr_type == "chlist":
+			h.Channels, h.BitDepth = exrChannels(value)
+		case name == "compression" && len(value) == 1:
+			h.Compression = fmt.Sprintf("unknown %d", value[0])
+			if int(value[0]) < len(exr_compressions) {
+				h.Compression = exr_compressions[value[0]]
+			}
+		case name == "displayWindow" && len(value) == 16:
+			h.Width = int(int32(binary.LittleEndian.Uint32(value[8:]))-int32(binary.LittleEndian.Uint32(value[0:]))) + 1
+			h.Height = int(int32(binary.LittleEndian.Uint32(value[12:]))-int32(binary.LittleEndian.Uint32(value[4:]))) + 1
+		case name == "timeCode" && len(value) == 8:
+			h.Timecode = formatTimecode(binary.LittleEndian.Uint32(value))
+		case name == "framesPerSecond" && len(value) == 8:
+			num := int32(binary.LittleEndian.Uint32(value))
+			den := binary.LittleEndian.Uint32(value[4:])
+			if den != 0 {
+				h.FrameRate = math.Round(float64(num)/float64(den)*1000) / 1000
+			}
+		}
+	}
+	if h.Channels == nil || h.Width == 0 {
+		return h, errors.New("no channels or display window")
+	}
+	return h, nil
+}
+
+//Return the channel names and largest bit depth of an EXR chlist attribute,
+//each channel is a name, a pixel type and 12 bytes of sampling
+func exrChannels(value []byte) ([]string, int) {
+	var names []string
+	depth := 0
+	for len(value) > 0 && value[0] != 0 {
+		end := 0
+		for end < len(value) && value[end] != 0 {
+			end++
+		}
+		if end+17 > len(value) {
+			break
+		}
+		names = append(names, string(value[:end]))
+		bits := 32
+		if binary.LittleEndian.Uint32(value[end+1:]) == 1 {
+			bits = 16
+		}
+		if bits > depth {
+			depth = bits
+		}
+		value = value[end+17:]
+	}
+	return names, depth
+}
+
+//Read a string ended by a 0 byte
+func readString(r *bufio.Reader) (string, error) {
+	s, read_err := r.ReadString(0)
+	if read_err != nil {
+		return "", read_err
+	}
+	return s[:len(s)-1], nil
+}
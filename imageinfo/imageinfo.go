@@ -0,0 +1,195 @@
+//Package imageinfo reads the headers of EXR, DPX, PNG, TIFF and JPEG frames
+//without decoding their pixels, so the frames of a sequence can be checked for
+//a mix of resolutions, bit depths or compressions after a bad re-render
+package imageinfo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Struct for the header of a frame, contains the following:
+//-Format is exr, dpx, png, tiff or jpeg
+//-Width and Height are the resolution, the display window of an EXR
+//-Channels is the names of the channels ie: R,G,B,A
+//-BitDepth is the bits of each channel, the largest when they differ
+//-Compression is the name of the compression ie: piz, none or deflate
+//-Timecode is the SMPTE timecode of a DPX or EXR frame ie: 01:00:00:00, drop
+//frame timecode uses ';' before the frames
+//-FrameRate is the frames per second of a DPX or EXR frame, 0 when not set
+type Header struct {
+	Format      string   `json:"format"`
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	Channels    []string `json:"channels"`
+	BitDepth    int      `json:"bit_depth"`
+	Compression string   `json:"compression"`
+	Timecode    string   `json:"timecode,omitempty"`
+	FrameRate   float64  `json:"fps,omitempty"`
+}
+
+//Return the parts of a header that should be the same for every frame of a
+//sequence, the timecode is left out as it changes every frame
+func (h Header) Signature() string {
+	sig := fmt.Sprintf("%s %dx%d %s %dbit %s", h.Format, h.Width, h.Height, strings.Join(h.Channels, ","), h.BitDepth, h.Compression)
+	if h.FrameRate > 0 {
+		sig += " " + strconv.FormatFloat(h.FrameRate, 'f', -1, 64) + "fps"
+	}
+	return sig
+}
+
+//Read the header of an image file, the format is found from the first bytes of
+//the file rather than its extension
+func Read(pth string) (Header, error) {
+	f, open_err := os.Open(pth)
+	if open_err != nil {
+		return Header{}, open_err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 8)
+	if _, read_err := io.ReadFull(f, magic); read_err != nil {
+		return Header{}, fmt.Errorf("%s is too short to be an image", pth)
+	}
+	var h Header
+	var read_err error
+	switch {
+	case bytes.Equal(magic[:4], []byte{0x76, 0x2f, 0x31, 0x01}):
+		h, read_err = readExr(f)
+	case string(magic[:4]) == "SDPX" || string(magic[:4]) == "XPDS":
+		h, read_err = readDpx(f)
+	case bytes.Equal(magic, []byte("\x89PNG\r\n\x1a\n")):
+		h, read_err = readPng(f)
+	case string(magic[:4]) == "II*\x00" || string(magic[:4]) == "MM\x00*":
+		h, read_err = readTiff(f)
+	case magic[0] == 0xff && magic[1] == 0xd8:
+		h, read_err = readJpeg(f)
+	default:
+		return h, fmt.Errorf("%s is not an EXR, DPX, PNG, TIFF or JPEG file", pth)
+	}
+	if read_err != nil {
+		return h, fmt.Errorf("Unable to read the header of %s - %v", pth, read_err)
+	}
+	return h, nil
+}
+
+//Struct for the headers of the frames of a sequence, contains the following:
+//-Header is the header of most of the frames, with the timecode of the first
+//-LastTimecode is the timecode of the last frame
+//-Frames is the number of online frames that were read
+//-Differ is the frames whose header is not the same as Header
+//-Unreadable is the frames whose header could not be read
+type SeqInfo struct {
+	Sequence     string       `json:"sequence"`
+	Header       Header       `json:"header"`
+	LastTimecode string       `json:"last_timecode,omitempty"`
+	Frames       int          `json:"frames"`
+	Differ       []FrameGroup `json:"differ,omitempty"`
+	Unreadable   string       `json:"unreadable,omitempty"`
+}
+
+//Struct for frames with the same header, Frames is a frame range ie: "1001-1010,1020"
+type FrameGroup struct {
+	Frames string `json:"frames"`
+	Header Header `json:"header"`
+}
+
+//Read the header of every online frame of a sequence and find the frames
+//whose header is different from most of the frames
+func Summarize(fs reducers.File_seq) (SeqInfo, error) {
+	info := SeqInfo{Sequence: fs.F_seq}
+	groups := make(map[string][]int)
+	headers := make(map[string]Header)
+	var order []string
+	var unreadable []int
+	first, last := -1, -1
+	for _, f := range fs.File_list {
+		pth := strings.Replace(fs.Base, `@`, fs.File_num[f], 1)
+		if _, stat_err := os.Stat(pth); os.IsNotExist(stat_err) {
+			continue
+		}
+		h, read_err := Read(pth)
+		if read_err != nil {
+			unreadable = append(unreadable, f)
+			continue
+		}
+		info.Frames++
+		sig := h.Signature()
+		if _, ok := groups[sig]; !ok {
+			order = append(order, sig)
+			headers[sig] = h
+		}
+		groups[sig] = append(groups[sig], f)
+		if first < 0 {
+			first = f
+			info.Header = h
+		}
+		last = f
+		info.LastTimecode = h.Timecode
+	}
+	info.Unreadable = expanders.Frame_range_string(unreadable)
+	if info.Frames == 0 {
+		return info, errors.New(fs.F_seq + " has no frames with a header that can be read")
+	}
+
+	//The most common header is the one the sequence should have, ties go to
+	//the header of the earliest frame
+	sort.SliceStable(order, func(i, j int) bool { return len(groups[order[i]]) > len(groups[order[j]]) })
+	timecode := info.Header.Timecode
+	if order[0] != info.Header.Signature() {
+		info.Header = headers[order[0]]
+	}
+	info.Header.Timecode = timecode
+	if first == last {
+		info.LastTimecode = ""
+	}
+	for _, sig := range order[1:] {
+		h := headers[sig]
+		h.Timecode = ""
+		info.Differ = append(info.Differ, FrameGroup{Frames: expanders.Frame_range_string(groups[sig]), Header: h})
+	}
+	return info, nil
+}
+
+//Format a timecode packed the way of SMPTE 12M, as used by DPX and EXR.  Each
+//field is binary coded decimal, the drop frame flag is bit 6.  Returns "" for
+//a timecode that is not set
+func formatTimecode(tc uint32) string {
+	if tc == 0xffffffff {
+		return ""
+	}
+	bcd := func(v uint32) int { return int(v>>4)*10 + int(v&0xf) }
+	sep := ":"
+	if tc&0x40 != 0 {
+		sep = ";"
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", bcd(tc>>24&0x3f), bcd(tc>>16&0x7f), bcd(tc>>8&0x7f), sep, bcd(tc&0x3f))
+}
+
+//Return the channel names for a number of channels of an image without names
+func channelNames(n int) []string {
+	switch n {
+	case 1:
+		return []string{"Y"}
+	case 2:
+		return []string{"Y", "A"}
+	case 3:
+		return []string{"R", "G", "B"}
+	case 4:
+		return []string{"R", "G", "B", "A"}
+	}
+	names := make([]string, n)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+//Return the header of a 1920x1080 half float RGB EXR with piz compression
+func exrHeader() []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0x76, 0x2f, 0x31, 0x01, 2, 0, 0, 0})
+	attr := func(name string, attr_type string, value []byte) {
+		b.WriteString(name + "\x00" + attr_type + "\x00")
+		binary.Write(&b, binary.LittleEndian, int32(len(value)))
+		b.Write(value)
+	}
+	var channels bytes.Buffer
+	for _, name := range []string{"B", "G", "R"} {
+		channels.WriteString(name + "\x00")
+		binary.Write(&channels, binary.LittleEndian, []int32{1, 0, 1, 1})
+	}
+	channels.WriteByte(0)
+	attr("channels", "chlist", channels.Bytes())
+	attr("compression", "compression", []byte{4})
+	var window bytes.Buffer
+	binary.Write(&window, binary.LittleEndian, []int32{0, 0, 1919, 1079})
+	attr("dataWindow", "box2i", window.Bytes())
+	attr("displayWindow", "box2i", window.Bytes())
+	attr("timeCode", "timecode", []byte{0x00, 0x00, 0x00, 0x01, 0, 0, 0, 0})
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+//Return the header of a 2048x1556 10 bit RGB DPX at 24 fps
+func dpxHeader() []byte {
+	b := make([]byte, dpx_header_len)
+	copy(b, "SDPX")
+	binary.BigEndian.PutUint16(b[dpx_elements:], 1)
+	binary.BigEndian.PutUint32(b[dpx_width:], 2048)
+	binary.BigEndian.PutUint32(b[dpx_height:], 1556)
+	b[dpx_descriptor] = 50
+	b[dpx_bitsize] = 10
+	binary.BigEndian.PutUint32(b[dpx_film_rate:], 0xffffffff)
+	binary.BigEndian.PutUint32(b[dpx_timecode:], 0x01000000)
+	binary.BigEndian.PutUint32(b[dpx_tv_rate:], math.Float32bits(24))
+	return b
+}
+
+//Return the header of a 640x480 8 bit RGB TIFF, the width is a short and the
+//height a long
+func tiffHeader() []byte {
+	var b bytes.Buffer
+	b.WriteString("II*\x00")
+	binary.Write(&b, binary.LittleEndian, uint32(8))
+	binary.Write(&b, binary.LittleEndian, uint16(4))
+	entry := func(tag uint16, field_type uint16, value uint32) {
+		binary.Write(&b, binary.LittleEndian, []uint16{tag, field_type})
+		binary.Write(&b, binary.LittleEndian, []uint32{1, value})
+	}
+	entry(tiff_width, 3, 640)
+	entry(tiff_height, 4, 480)
+	entry(tiff_bits, 3, 8)
+	entry(tiff_samples, 3, 3)
+	return b.Bytes()
+}
+
+//Return the signature and IHDR chunk of a 320x240 8 bit RGBA PNG
+func pngHeader() []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&b, binary.BigEndian, uint32(13))
+	b.WriteString("IHDR")
+	binary.Write(&b, binary.BigEndian, []uint32{320, 240})
+	b.Write([]byte{8, 6, 0, 0, 0})
+	binary.Write(&b, binary.BigEndian, uint32(0))
+	return b.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		tc   string
+	}{
+		{"exr", exrHeader(), "exr 1920x1080 B,G,R 16bit piz", "01:00:00:00"},
+		{"dpx", dpxHeader(), "dpx 2048x1556 R,G,B 10bit none 24fps", "01:00:00:00"},
+		{"tiff", tiffHeader(), "tiff 640x480 R,G,B 8bit none", ""},
+		{"png", pngHeader(), "png 320x240 R,G,B,A 8bit deflate", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "frame."+tt.name)
+			if write_err := ioutil.WriteFile(pth, tt.data, 0644); write_err != nil {
+				t.Fatal(write_err)
+			}
+			h, read_err := Read(pth)
+			if read_err != nil {
+				t.Fatalf("Read() error = %v", read_err)
+			}
+			if h.Signature() != tt.want {
+				t.Errorf("Read() = %q, want %q", h.Signature(), tt.want)
+			}
+			if h.Timecode != tt.tc {
+				t.Errorf("Read() timecode = %q, want %q", h.Timecode, tt.tc)
+			}
+		})
+	}
+}
+
+//A frame cut off anywhere in its header, ie: by a failed copy, is an error
+//rather than a header of zeros
+func TestReadTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"exr", exrHeader()},
+		{"dpx", dpxHeader()},
+		{"tiff", tiffHeader()},
+		{"png", pngHeader()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for n := 0; n < len(tt.data); n++ {
+				pth := filepath.Join(dir, "frame."+strconv.Itoa(n)+"."+tt.name)
+				if write_err := ioutil.WriteFile(pth, tt.data[:n], 0644); write_err != nil {
+					t.Fatal(write_err)
+				}
+				if h, read_err := Read(pth); read_err == nil {
+					t.Fatalf("Read() of %d of %d bytes = %q, want an error", n, len(tt.data), h.Signature())
+				}
+			}
+		})
+	}
+}
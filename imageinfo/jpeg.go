@@ -0,0 +1,77 @@
+package imageinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+//Read the start of frame segment of a JPEG file, the segments before it are
+//skipped
+func readJpeg(f *os.File) (Header, error) {
+	h := Header{Format: "jpeg"}
+	r := bufio.NewReader(io.NewSectionReader(f, 2, 1<<30))
+	for {
+		marker, marker_err := r.ReadByte()
+		if marker_err != nil {
+			return h, errors.New("no start of frame")
+		}
+		if marker != 0xff {
+			continue
+		}
+		kind, kind_err := r.ReadByte()
+		if kind_err != nil {
+			return h, kind_err
+		}
+		//Markers without a length
+		if kind == 0xff || kind == 0x00 || kind == 0x01 || (kind >= 0xd0 && kind <= 0xd9) {
+			if kind == 0xff {
+				r.UnreadByte()
+			}
+			continue
+		}
+		length_buf := make([]byte, 2)
+		if _, read_err := io.ReadFull(r, length_buf); read_err != nil {
+			return h, read_err
+		}
+		length := int(binary.BigEndian.Uint16(length_buf)) - 2
+		if length < 0 {
+			return h, errors.New("invalid segment length")
+		}
+		segment := make([]byte, length)
+		if _, read_err := io.ReadFull(r, segment); read_err != nil {
+			return h, read_err
+		}
+		//Start of frame markers are 0xc0 to 0xcf, except 0xc4, 0xc8 and 0xcc
+		if kind < 0xc0 || kind > 0xcf || kind == 0xc4 || kind == 0xc8 || kind == 0xcc {
+			continue
+		}
+		if len(segment) < 6 {
+			return h, errors.New("short start of frame")
+		}
+		h.BitDepth = int(segment[0])
+		h.Height = int(binary.BigEndian.Uint16(segment[1:]))
+		h.Width = int(binary.BigEndian.Uint16(segment[3:]))
+		switch segment[5] {
+		case 1:
+			h.Channels = []string{"Y"}
+		case 3:
+			h.Channels = []string{"Y", "Cb", "Cr"}
+		case 4:
+			h.Channels = []string{"C", "M", "Y", "K"}
+		default:
+			h.Channels = channelNames(int(segment[5]))
+		}
+		switch kind & 0x03 {
+		case 2:
+			h.Compression = "progressive"
+		case 3:
+			h.Compression = "lossless"
+		default:
+			h.Compression = "baseline"
+		}
+		return h, nil
+	}
+}
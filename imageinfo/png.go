@@ -0,0 +1,44 @@
+package imageinfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+//Read the IHDR chunk of a PNG file, it is always the first chunk.  A file
+//shorter than the chunk is an error
+func readPng(f *os.File) (Header, error) {
+	h := Header{Format: "png", Compression: "deflate"}
+	buf := make([]byte, 25)
+	if _, read_err := f.ReadAt(buf, 8); read_err == io.EOF {
+		return h, errors.New("the IHDR chunk is truncated")
+	} else if read_err != nil {
+		return h, read_err
+	}
+	if string(buf[4:8]) != "IHDR" {
+		return h, errors.New("no IHDR chunk")
+	}
+	h.Width = int(binary.BigEndian.Uint32(buf[8:]))
+	h.Height = int(binary.BigEndian.Uint32(buf[12:]))
+	h.BitDepth = int(buf[16])
+	switch buf[17] {
+	case 0:
+		h.Channels = channelNames(1)
+	case 2:
+		h.Channels = channelNames(3)
+	case 3:
+		h.Channels = []string{"P"}
+	case 4:
+		h.Channels = channelNames(2)
+	case 6:
+		h.Channels = channelNames(4)
+	default:
+		return h, errors.New("unknown color type")
+	}
+	if buf[20] == 1 {
+		h.Compression = "deflate interlaced"
+	}
+	return h, nil
+}
@@ -0,0 +1,112 @@
+package imageinfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+//Tags of a TIFF image file directory
+const (
+	tiff_width       = 256
+	tiff_height      = 257
+	tiff_bits        = 258
+	tiff_compression = 259
+	tiff_samples     = 277
+)
+
+//Names of TIFF compressions by number
+var tiff_compressions = map[int]string{
+	1:     "none",
+	2:     "ccitt",
+	5:     "lzw",
+	6:     "jpeg",
+	7:     "jpeg",
+	8:     "deflate",
+	32773: "packbits",
+	32946: "deflate",
+	34925: "lzma",
+	50000: "zstd",
+}
+
+//Read the first image file directory of a TIFF file, the first two bytes give
+//the byte order
+func readTiff(f *os.File) (Header, error) {
+	h := Header{Format: "tiff", BitDepth: 1, Compression: "none"}
+	buf := make([]byte, 8)
+	if _, read_err := f.ReadAt(buf, 0); read_err != nil {
+		return h, read_err
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if buf[0] == 'I' {
+		order = binary.LittleEndian
+	}
+	ifd := int64(order.Uint32(buf[4:]))
+	count_buf := make([]byte, 2)
+	if _, read_err := f.ReadAt(count_buf, ifd); read_err != nil {
+		return h, read_err
+	}
+	entries := make([]byte, 12*int(order.Uint16(count_buf)))
+	if _, read_err := f.ReadAt(entries, ifd+2); read_err == io.EOF {
+		return h, errors.New("the image file directory is truncated")
+	} else if read_err != nil {
+		return h, read_err
+	}
+
+	samples := 1
+	for i := 0; i+12 <= len(entries); i += 12 {
+		entry := entries[i : i+12]
+		tag := order.Uint16(entry)
+		value, value_err := tiffValue(f, order, entry)
+		if value_err != nil {
+			return h, value_err
+		}
+		switch tag {
+		case tiff_width:
+			h.Width = value
+		case tiff_height:
+			h.Height = value
+		case tiff_bits:
+			h.BitDepth = value
+		case tiff_compression:
+			name, ok := tiff_compressions[value]
+			if !ok {
+				name = fmt.Sprintf("unknown %d", value)
+			}
+			h.Compression = name
+		case tiff_samples:
+			samples = value
+		}
+	}
+	if h.Width == 0 || h.Height == 0 {
+		return h, errors.New("no width or height")
+	}
+	h.Channels = channelNames(samples)
+	return h, nil
+}
+
+//Return the first value of an image file directory entry.  Values of 4 bytes
+//or less are in the entry, larger ones are at the offset it holds
+func tiffValue(f *os.File, order binary.ByteOrder, entry []byte) (int, error) {
+	field_type := order.Uint16(entry[2:])
+	count := order.Uint32(entry[4:])
+	size := uint32(2)
+	if field_type == 4 {
+		size = 4
+	} else if field_type != 3 {
+		return 0, nil
+	}
+	data := entry[8:12]
+	if size*count > 4 {
+		data = make([]byte, size)
+		if _, read_err := f.ReadAt(data, int64(order.Uint32(entry[8:]))); read_err != nil {
+			return 0, read_err
+		}
+	}
+	if size == 2 {
+		return int(order.Uint16(data)), nil
+	}
+	return int(order.Uint32(data)), nil
+}
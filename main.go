@@ -3,6 +3,8 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -10,7 +12,11 @@ import (
 
 	"github.com/mattbro2/filesequence/commands"
 	"github.com/mattbro2/filesequence/core"
+	"github.com/mattbro2/filesequence/export"
 	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/seq_manip"
+	"github.com/mattbro2/filesequence/timecode"
+	"github.com/mattbro2/filesequence/watch"
 
 	"github.com/daviddengcn/go-colortext"
 )
@@ -19,6 +25,38 @@ import (
 func main() {
 	options := commands.InitCommands(os.Stdout)
 	reader := bufio.NewReader(os.Stdin)
+	manip_opts, opts_err := core.ManipOptions(options.Force, options.Link, options.Preserve, options.Progress, options.Verbose)
+	if opts_err != nil {
+		fmt.Println(opts_err)
+		os.Exit(1)
+		return
+	}
+	limit, limit_err := core.Limiter(options.BwLimit, options.FileLimit, options.ThrottleFile)
+	if limit_err != nil {
+		fmt.Println(limit_err)
+		os.Exit(1)
+		return
+	}
+	manip_opts.Limit = limit
+	manip_opts.Workers = options.Workers
+	if flag_err := checkPreview(options); flag_err != nil {
+		fmt.Println(flag_err)
+		os.Exit(1)
+		return
+	}
+	//Timecode ranges are converted to frame numbers before they are used, without
+	//-tcframe -tcstart is the timecode of the first frame of the source
+	source := firstListing(options.Copy, options.Move, options.Reseq, options.Delete)
+	var tc_err error
+	options.Frames, tc_err = core.TimecodeFrames(options.Frames, options.Fps, options.TcStart, options.TcFrame, source)
+	if tc_err == nil {
+		options.Range, tc_err = core.TimecodeFrames(options.Range, options.Fps, options.TcStart, options.TcFrame, options.Fill)
+	}
+	if tc_err != nil {
+		fmt.Println(tc_err)
+		os.Exit(1)
+		return
+	}
 	//If the user wants a file list from a File_seq object
 	if options.Reverse != "" {
 		fseq, rvseq_err := core.ReverseSeqMain(options.Reverse)
@@ -28,6 +66,18 @@ func main() {
 			return
 		}
 
+		//With a format the sequence is printed instead of its files
+		if options.Format != "native" {
+			listing, fmt_err := fseq.Format(options.Format)
+			if fmt_err != nil {
+				fmt.Println(fmt_err)
+				os.Exit(1)
+				return
+			}
+			fmt.Println(listing)
+			return
+		}
+
 		reverse, rev_err := core.ReverseMain(fseq)
 		if rev_err != nil {
 			fmt.Printf("Unable to list files from sequence %s - %s\n", options.Reverse, rev_err)
@@ -53,15 +103,43 @@ func main() {
 		return
 	}
 
+	//Apply one operation to many File_seqs
+	if options.Batch != "" || options.BatchFile != "" {
+		err := batchSeqs(options, manip_opts, reader)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+			return
+		}
+		return
+	}
+
 	//Copy one File_seq to another
 	if options.Copy != "" {
-		fs_split := strings.Split(options.Copy, "::")
-		if len(fs_split) != 2 {
-			fmt.Printf("-c param %s not two fseqs separated by '::'\n", options.Copy)
+		fs_split, split_err := splitSeqs(options.Copy, options)
+		if split_err != nil {
+			fmt.Printf("-c param %s %s\n", options.Copy, split_err)
 			os.Exit(1)
 			return
 		}
-		err := core.CopySeqMain(fs_split[0], fs_split[1], options.Force, options.Verbose)
+		if options.Map != "" && options.Preview {
+			files_source, files_dest, err := core.MapPreviewMain(fs_split[0], fs_split[1], options.Map)
+			if err != nil {
+				fmt.Printf("Unable to map frames %s\n", err)
+				os.Exit(1)
+				return
+			}
+			for i, _ := range files_source {
+				fmt.Printf("%s -> %s\n", files_source[i], files_dest[i])
+			}
+			return
+		}
+		var err error
+		if options.Map != "" {
+			err = core.CopyMapSeqMain(fs_split[0], fs_split[1], options.Map, manip_opts)
+		} else {
+			err = core.CopySeqMain(fs_split[0], fs_split[1], manip_opts)
+		}
 		if err != nil {
 			fmt.Printf("Unable to copy files %s\n", err)
 			os.Exit(1)
@@ -70,15 +148,27 @@ func main() {
 		return
 	}
 
+	//Create the missing frames of a file_seq
+	if options.Fill != "" {
+		created, err := core.FillSeqMain(options.Fill, options.Range, options.FillFrom, options.Sidecar, manip_opts)
+		if err != nil {
+			fmt.Printf("Unable to fill frames %s\n", err)
+			os.Exit(1)
+			return
+		}
+		fmt.Printf("%d missing frames created\n", len(created))
+		return
+	}
+
 	//Move from one file_seq to another
 	if options.Move != "" {
-		fs_split := strings.Split(options.Move, "::")
-		if len(fs_split) != 2 {
-			fmt.Printf("-c param %s not two fseqs separated by '::'\n", options.Move)
+		fs_split, split_err := splitSeqs(options.Move, options)
+		if split_err != nil {
+			fmt.Printf("-m param %s %s\n", options.Move, split_err)
 			os.Exit(1)
 			return
 		}
-		err := core.MoveSeqMain(fs_split[0], fs_split[1], options.Force, options.Verbose)
+		err := core.MoveSeqMain(fs_split[0], fs_split[1], manip_opts, options.UndoDir)
 		if err != nil {
 			fmt.Printf("Unable to move files %s\n", err)
 			os.Exit(1)
@@ -86,15 +176,16 @@ func main() {
 		return
 	}
 
-	//Renumber a sequence of files, will allow overwriting
+	//Renumber a sequence of files, frames that are not part of the renumber are
+	//only overwritten with -f
 	if options.Reseq != "" {
-		fs_split := strings.Split(options.Reseq, "::")
-		if len(fs_split) != 2 {
-			fmt.Printf("-q param %s not two fseqs separated by '::'\n", options.Reseq)
+		fs_split, split_err := splitSeqs(options.Reseq, options)
+		if split_err != nil {
+			fmt.Printf("-q param %s %s\n", options.Reseq, split_err)
 			os.Exit(1)
 			return
 		}
-		err := core.ReSeqMain(fs_split[0], fs_split[1], options.Verbose)
+		err := core.ReSeqMain(fs_split[0], fs_split[1], options.Trash, manip_opts, options.UndoDir)
 		if err != nil {
 			fmt.Printf("Unable to resequence files %s\n", err)
 			os.Exit(1)
@@ -104,7 +195,16 @@ func main() {
 
 	//Delete a file seq
 	if options.Delete != "" {
-		if !options.Force {
+		if options.Frames != "" {
+			fs, filter_err := core.FilterListing(options.Delete, options.Frames)
+			if filter_err != nil {
+				fmt.Printf("-d param %s %s\n", options.Delete, filter_err)
+				os.Exit(1)
+				return
+			}
+			options.Delete = fs
+		}
+		if !options.Force && !options.Yes {
 			fmt.Println("This will remove your data, are you sure? [y/n]: ")
 			response, err := reader.ReadString('\n')
 			if err != nil {
@@ -119,7 +219,7 @@ func main() {
 				return
 			}
 		}
-		err := core.DeleteSeqMain(options.Delete, options.Force, options.Verbose)
+		err := core.DeleteSeqMain(options.Delete, options.Trash, manip_opts, options.UndoDir)
 		if err != nil {
 			fmt.Printf("Error occurred %s ", err)
 			os.Exit(1)
@@ -128,6 +228,374 @@ func main() {
 		return
 	}
 
+	//Write a checksum manifest of a file_seq
+	if options.Manifest != "" {
+		pth, entries, err := core.ManifestMain(options.Manifest, options.ManifestFile, options.ManifestFormat, options.Force)
+		if err != nil {
+			fmt.Printf("Unable to write manifest of %s - %s\n", options.Manifest, err)
+			os.Exit(1)
+			return
+		}
+		fmt.Printf("%d frames written to %s\n", len(entries), pth)
+		return
+	}
+
+	//Check a file_seq against its manifest
+	if options.Verify != "" {
+		report, err := core.VerifyMain(options.Verify)
+		if err != nil {
+			fmt.Printf("Unable to verify %s - %s\n", options.Verify, err)
+			os.Exit(1)
+			return
+		}
+		for _, x := range report.Missing {
+			fmt.Printf("missing %s\n", x)
+		}
+		for _, x := range report.Changed {
+			fmt.Printf("changed %s\n", x)
+		}
+		for _, x := range report.Zero {
+			fmt.Printf("zero-byte %s\n", x)
+		}
+		for _, x := range report.Extra {
+			fmt.Printf("extra %s\n", x)
+		}
+		fmt.Printf("%s: %d ok, %d missing, %d changed, %d zero-byte, %d extra\n", options.Verify,
+			len(report.Ok), len(report.Missing), len(report.Changed), len(report.Zero), len(report.Extra))
+		if !report.Clean() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Copy the missing or changed frames of file_seqs or directories
+	if options.Sync != "" {
+		split := strings.Split(options.Sync, "::")
+		if len(split) == 2 && !filesys.IsDir(split[0]) {
+			var split_err error
+			split, split_err = splitSeqs(options.Sync, options)
+			if split_err != nil {
+				fmt.Printf("-sync param %s %s\n", options.Sync, split_err)
+				os.Exit(1)
+				return
+			}
+		}
+		if len(split) != 2 {
+			fmt.Printf("-sync param %s not two fseqs or directories separated by '::'\n", options.Sync)
+			os.Exit(1)
+			return
+		}
+		synced, err := core.SyncMain(split[0], split[1], options.Checksum, options.Prune, options.Trash, options.Preview, manip_opts, options.UndoDir)
+		copied, current, deleted := 0, 0, 0
+		for _, x := range synced {
+			copied += len(x.Result.Copied)
+			current += x.Result.Current
+			deleted += len(x.Result.Deleted)
+			if options.Preview {
+				for _, f := range x.Result.Copied {
+					fmt.Printf("copy %s\n", f)
+				}
+				for _, f := range x.Result.Deleted {
+					fmt.Printf("delete %s\n", f)
+				}
+				continue
+			}
+			if len(x.Result.Copied)+len(x.Result.Deleted) > 0 || options.Verbose {
+				fmt.Printf("%s: %d copied, %d current, %d deleted\n", x.Sequence, len(x.Result.Copied), x.Result.Current, len(x.Result.Deleted))
+			}
+		}
+		if err != nil {
+			fmt.Printf("Unable to sync %s - %s\n", options.Sync, err)
+			os.Exit(1)
+			return
+		}
+		fmt.Printf("%d sequences, %d frames copied, %d current, %d deleted\n", len(synced), copied, current, deleted)
+		return
+	}
+
+	//Compare two file_seqs or directories
+	if options.Diff != "" {
+		split := strings.Split(options.Diff, "::")
+		if len(split) != 2 {
+			fmt.Printf("-diff param %s not two fseqs or directories separated by '::'\n", options.Diff)
+			os.Exit(1)
+			return
+		}
+		diffs, err := core.DiffMain(split[0], split[1], options.Checksum)
+		if err != nil {
+			fmt.Printf("Unable to compare %s - %s\n", options.Diff, err)
+			os.Exit(1)
+			return
+		}
+		same := 0
+		for _, d := range diffs {
+			if d.Status == "same" {
+				same++
+			}
+		}
+		if options.Json {
+			out, json_err := json.MarshalIndent(diffs, "", "  ")
+			if json_err != nil {
+				fmt.Println(json_err)
+				os.Exit(1)
+				return
+			}
+			fmt.Println(string(out))
+		} else {
+			for _, d := range diffs {
+				if d.Status == "same" {
+					continue
+				}
+				fmt.Printf("%-8s %s", d.Status, d.Sequence)
+				if d.Gained != "" {
+					fmt.Printf("  gained %s", d.Gained)
+				}
+				if d.Lost != "" {
+					fmt.Printf("  lost %s", d.Lost)
+				}
+				if d.Changed != "" {
+					fmt.Printf("  differ %s", d.Changed)
+				}
+				fmt.Println()
+			}
+			fmt.Printf("%d sequences differ, %d the same\n", len(diffs)-same, same)
+		}
+		//Like diff, exit with 1 when anything is different
+		if same != len(diffs) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Check sequences for frames to render again
+	if options.Health != "" {
+		reports, err := core.HealthMain(options.Health, options.HealthRatio, options.HealthWindow, options.Verbose)
+		if err != nil {
+			fmt.Printf("Unable to check %s - %s\n", options.Health, err)
+			os.Exit(1)
+			return
+		}
+		unhealthy := 0
+		for _, r := range reports {
+			if !r.Healthy() {
+				unhealthy++
+			}
+		}
+		if options.Json {
+			out, json_err := json.MarshalIndent(reports, "", "  ")
+			if json_err != nil {
+				fmt.Println(json_err)
+				os.Exit(1)
+				return
+			}
+			fmt.Println(string(out))
+		} else {
+			for _, r := range reports {
+				if r.Healthy() {
+					if options.Verbose {
+						fmt.Printf("ok %s\n", r.Sequence)
+					}
+					continue
+				}
+				fmt.Println(r.Sequence)
+				for _, x := range [][2]string{{"missing", r.Missing}, {"zero-byte", r.Zero}, {"truncated", r.Truncated}, {"unreadable", r.Unreadable}, {"render", r.Bad}} {
+					if x[1] != "" {
+						fmt.Printf("  %-10s %s\n", x[0], x[1])
+					}
+				}
+			}
+			fmt.Printf("%d sequences checked, %d with frames to render again\n", len(reports), unhealthy)
+		}
+		if unhealthy > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Summarize the image headers of a sequence
+	if options.Info != "" {
+		info, err := core.InfoMain(options.Info)
+		if err != nil {
+			fmt.Printf("Unable to read %s - %s\n", options.Info, err)
+			os.Exit(1)
+			return
+		}
+		if options.Json {
+			out, json_err := json.MarshalIndent(info, "", "  ")
+			if json_err != nil {
+				fmt.Println(json_err)
+				os.Exit(1)
+				return
+			}
+			fmt.Println(string(out))
+		} else {
+			h := info.Header
+			fmt.Println(info.Sequence)
+			fmt.Printf("  %-12s %s\n", "format", h.Format)
+			fmt.Printf("  %-12s %dx%d\n", "resolution", h.Width, h.Height)
+			fmt.Printf("  %-12s %d (%s)\n", "channels", len(h.Channels), strings.Join(h.Channels, ","))
+			fmt.Printf("  %-12s %d\n", "bit depth", h.BitDepth)
+			fmt.Printf("  %-12s %s\n", "compression", h.Compression)
+			if h.Timecode != "" {
+				timecode := h.Timecode
+				if info.LastTimecode != "" {
+					timecode += " - " + info.LastTimecode
+				}
+				fmt.Printf("  %-12s %s\n", "timecode", timecode)
+			}
+			if h.FrameRate > 0 {
+				fmt.Printf("  %-12s %v\n", "fps", h.FrameRate)
+			}
+			for _, g := range info.Differ {
+				fmt.Printf("  differ %s: %s\n", g.Frames, g.Header.Signature())
+			}
+			if info.Unreadable != "" {
+				fmt.Printf("  unreadable %s\n", info.Unreadable)
+			}
+			fmt.Printf("%d frames read, %d groups of frames differ\n", info.Frames, len(info.Differ))
+		}
+		if len(info.Differ) > 0 || info.Unreadable != "" {
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Print the ffmpeg input arguments of a File_seq
+	if options.Ffmpeg != "" {
+		args, err := core.FfmpegMain(options.Ffmpeg, options.Fps, options.Ffconcat, options.Force)
+		if err != nil {
+			fmt.Printf("Unable to export %s - %s\n", options.Ffmpeg, err)
+			os.Exit(1)
+			return
+		}
+		fmt.Println(export.ShellJoin(args))
+		return
+	}
+
+	//Print the File_seqs as an edit
+	if options.Edl != "" || options.Otio != "" {
+		listings, format := options.Edl, "edl"
+		if options.Otio != "" {
+			listings, format = options.Otio, "otio"
+		}
+		out, err := core.ExportMain(listings, format, options.Fps, options.TcStart, options.TcFrame, options.Missing)
+		if err != nil {
+			fmt.Printf("Unable to export %s - %s\n", listings, err)
+			os.Exit(1)
+			return
+		}
+		fmt.Print(out)
+		return
+	}
+
+	//Print the File_seqs of an OpenTimelineIO file
+	if options.ReadOtio != "" {
+		listings, err := core.OtioListings(options.ReadOtio)
+		if err != nil {
+			fmt.Printf("Unable to read %s - %s\n", options.ReadOtio, err)
+			os.Exit(1)
+			return
+		}
+		for _, x := range listings {
+			fmt.Println(x)
+		}
+		return
+	}
+
+	//Follow the File_seqs of a directory as they are written
+	if options.Watch != "" {
+		err := core.WatchMain(options.Watch, options.Range, options.Poll, func(e watch.Event) {
+			if options.Json {
+				out, _ := json.Marshal(e)
+				fmt.Println(string(out))
+				return
+			}
+			fmt.Printf("%s %-8s %s  %s\n", e.Time.Format("15:04:05"), e.Event, e.Sequence, e.Frames)
+		})
+		if err != nil {
+			fmt.Printf("Unable to watch %s - %s\n", options.Watch, err)
+			os.Exit(1)
+			return
+		}
+		return
+	}
+
+	//Reverse an operation from the undo log
+	if options.Undo != "" {
+		err := core.UndoMain(options.Undo, options.Verbose, options.UndoDir)
+		if err != nil {
+			fmt.Printf("Unable to undo %s - %s\n", options.Undo, err)
+			os.Exit(1)
+			return
+		}
+		return
+	}
+
+	//List the operations in the undo log
+	if options.History {
+		ops, err := core.HistoryMain(options.UndoDir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+			return
+		}
+		for _, op := range ops {
+			fmt.Printf("%s\t%d files\t%s -> %s\n", op.Id, len(op.Pairs), op.Pairs[0].Source, op.Pairs[0].Dest)
+		}
+		return
+	}
+
+	//List the sequences in the trash
+	if options.TrashLs {
+		trashed, err := core.TrashListMain()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+			return
+		}
+		for _, x := range trashed {
+			fmt.Printf("%s\t%s\n", x.DeletionDate.Format("2006-01-02 15:04:05"), x.Fseq.F_seq)
+		}
+		return
+	}
+
+	//Restore a sequence from the trash
+	if options.Restore != "" {
+		err := core.RestoreMain(options.Restore, options.Verbose)
+		if err != nil {
+			fmt.Printf("Unable to restore %s - %s\n", options.Restore, err)
+			os.Exit(1)
+			return
+		}
+		return
+	}
+
+	//Permanently remove a sequence from the trash
+	if options.Purge != "" {
+		if !options.Yes {
+			fmt.Println("This will permanently remove your data, are you sure? [y/n]: ")
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				fmt.Printf("error occurred %s", err)
+				os.Exit(1)
+				return
+			}
+			response = strings.ToLower(strings.TrimSpace(response))
+			if response != "y" {
+				fmt.Println("Not continuing with purge, reponse was not 'y'")
+				os.Exit(1)
+				return
+			}
+		}
+		err := core.PurgeMain(options.Purge, options.Verbose)
+		if err != nil {
+			fmt.Printf("Unable to purge %s - %s\n", options.Purge, err)
+			os.Exit(1)
+			return
+		}
+		return
+	}
+
 	//Default behavior of doing a file_seq listing
 	file_seqs, err := core.ListMain(options.Curdir, options.Verbose)
 
@@ -139,8 +607,37 @@ func main() {
 
 	var fmt_seqs []string
 
+	//With a frame rate, listings show their duration
+	var rate timecode.Rate
+	if options.Fps != "" {
+		var rate_err error
+		rate, rate_err = timecode.ParseRate(options.Fps)
+		if rate_err != nil {
+			fmt.Println(rate_err)
+			os.Exit(1)
+			return
+		}
+	}
+
 	for _, x := range file_seqs {
-		fmt_seqs = append(fmt_seqs, x.F_seq)
+		listing, fmt_err := x.Format(options.Format)
+		if fmt_err != nil {
+			fmt.Println(fmt_err)
+			os.Exit(1)
+			return
+		}
+		x.F_seq = listing
+		if options.Fps == "" {
+			fmt_seqs = append(fmt_seqs, x.F_seq)
+			continue
+		}
+		listing, dur_err := core.DurationListing(x, rate, options.TcStart, options.TcFrame)
+		if dur_err != nil {
+			fmt.Println(dur_err)
+			os.Exit(1)
+			return
+		}
+		fmt_seqs = append(fmt_seqs, listing)
 	}
 
 	sort.Strings(fmt_seqs)
@@ -151,3 +648,174 @@ func main() {
 
 	return
 }
+
+//Return the source listing of the first param that is given, params are
+//"source::dest" or a single listing
+func firstListing(params ...string) string {
+	for _, x := range params {
+		if x != "" {
+			return strings.Split(x, "::")[0]
+		}
+	}
+	return ""
+}
+
+//-preview must never change anything, so it is refused by the operations that
+//can not preview.  -map only retimes copies
+func checkPreview(options commands.Options) error {
+	is_batch := options.Batch != "" || options.BatchFile != ""
+	map_copy := options.Copy != "" && !is_batch
+	if options.Map != "" && !map_copy && !(is_batch && options.Op == "copy") {
+		return errors.New("-map only retimes a copy, use it with -c or -op copy")
+	}
+	if options.Preview && !(map_copy && options.Map != "") && options.Sync == "" {
+		return errors.New("-preview is only supported with -c and -map, or -sync, nothing was changed")
+	}
+	return nil
+}
+
+//Split a "source::dest" param into its two fseq listings.  With -frames the
+//source is filtered to those frames first, and the dest filtered to match.
+//The dest may be a template or directory which is rendered from the source.
+//When only the source is given the dest is derived from it using -offset,
+//-start or -pad
+func splitSeqs(param string, options commands.Options) ([]string, error) {
+	fs_split := strings.Split(param, "::")
+	is_template := len(fs_split) == 2 && core.IsTemplate(fs_split[1])
+	if options.Frames != "" && len(fs_split) <= 2 {
+		if len(fs_split) == 2 && !is_template {
+			fd, filter_err := core.FilterDestListing(fs_split[0], fs_split[1], options.Frames)
+			if filter_err != nil {
+				return fs_split, filter_err
+			}
+			fs_split[1] = fd
+		}
+		fs, filter_err := core.FilterListing(fs_split[0], options.Frames)
+		if filter_err != nil {
+			return fs_split, filter_err
+		}
+		fs_split[0] = fs
+	}
+	if is_template {
+		fd, tmpl_err := core.TemplateListing(fs_split[0], fs_split[1], options.Match)
+		if tmpl_err != nil {
+			return fs_split, tmpl_err
+		}
+		fs_split[1] = fd
+	}
+	if len(fs_split) == 1 && (options.Offset != "" || options.Start != "" || options.Pad != -1) {
+		fd, rn_err := core.RenumberListing(fs_split[0], options.Offset, options.Start, options.Pad)
+		if rn_err != nil {
+			return fs_split, rn_err
+		}
+		fs_split = append(fs_split, fd)
+	}
+	if len(fs_split) != 2 {
+		return fs_split, errors.New("not two fseqs separated by '::'")
+	}
+	return fs_split, nil
+}
+
+//Apply -op to every sequence of a batch.  The operations are listed and
+//confirmed once, then applied in turn, a sequence that fails does not stop
+//the others and the failures are reported in the summary
+func batchSeqs(options commands.Options, manip_opts seq_manip.Options, reader *bufio.Reader) error {
+	switch options.Op {
+	case "copy", "move", "delete", "reseq":
+	default:
+		return fmt.Errorf("-op %q must be one of copy, move, delete or reseq", options.Op)
+	}
+	if options.Dest != "" && !core.IsTemplate(options.Dest) {
+		return fmt.Errorf("-dest %s must be a directory or template", options.Dest)
+	}
+	listings, list_err := core.BatchListings(options.Batch, options.BatchFile, options.Match, options.Exclude, options.Verbose)
+	if list_err != nil {
+		return list_err
+	}
+
+	var plan [][]string
+	var failed []string
+	for _, x := range listings {
+		if options.Op == "delete" {
+			fs := strings.Split(x, "::")[0]
+			if options.Frames != "" {
+				var filter_err error
+				fs, filter_err = core.FilterListing(fs, options.Frames)
+				if filter_err != nil {
+					failed = append(failed, fmt.Sprintf("%s - %s", x, filter_err))
+					continue
+				}
+			}
+			plan = append(plan, []string{fs})
+			continue
+		}
+		param := x
+		if !strings.Contains(x, "::") && options.Dest != "" {
+			param = x + "::" + options.Dest
+		}
+		fs_split, split_err := splitSeqs(param, options)
+		if split_err != nil {
+			failed = append(failed, fmt.Sprintf("%s - %s", x, split_err))
+			continue
+		}
+		plan = append(plan, fs_split)
+	}
+
+	for _, x := range failed {
+		fmt.Printf("skipping %s\n", x)
+	}
+	if len(plan) == 0 {
+		return errors.New("No sequences to " + options.Op)
+	}
+	for _, x := range plan {
+		if len(x) == 1 {
+			fmt.Printf("%s %s\n", options.Op, x[0])
+			continue
+		}
+		fmt.Printf("%s %s -> %s\n", options.Op, x[0], x[1])
+	}
+	if !options.Yes {
+		fmt.Printf("This will %s %d sequences, are you sure? [y/n]: \n", options.Op, len(plan))
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("error occurred %s", err)
+		}
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "y" {
+			return fmt.Errorf("Not continuing with %s, reponse was not 'y'", options.Op)
+		}
+	}
+
+	done := 0
+	for _, x := range plan {
+		var err error
+		switch options.Op {
+		case "copy":
+			if options.Map != "" {
+				err = core.CopyMapSeqMain(x[0], x[1], options.Map, manip_opts)
+			} else {
+				err = core.CopySeqMain(x[0], x[1], manip_opts)
+			}
+		case "move":
+			err = core.MoveSeqMain(x[0], x[1], manip_opts, options.UndoDir)
+		case "reseq":
+			err = core.ReSeqMain(x[0], x[1], options.Trash, manip_opts, options.UndoDir)
+		case "delete":
+			err = core.DeleteSeqMain(x[0], options.Trash, manip_opts, options.UndoDir)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s - %s", x[0], strings.TrimSpace(err.Error())))
+			continue
+		}
+		done++
+	}
+
+	fmt.Printf("%s: %d sequences done, %d failed\n", options.Op, done, len(failed))
+	for _, x := range failed {
+		fmt.Printf("  %s\n", x)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d sequences failed", len(failed))
+	}
+	return nil
+}
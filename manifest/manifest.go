@@ -0,0 +1,396 @@
+//Package manifest writes and checks checksum manifests of the frames of a
+//sequence, so a delivery can be proven intact later.  Two formats are supported:
+//-sha256 is the format of sha256sum, it may be checked with 'sha256sum -c'.  The
+//size and modification time of each frame are kept in a comment line before it
+//-mhl is an ASC MHL (media hash list) XML file with md5 hashes
+//Paths are stored relative to the directory of the manifest
+package manifest
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Manifest formats
+const (
+	Sha256 = "sha256"
+	Mhl    = "mhl"
+)
+
+//Struct for one frame of a manifest, Path is absolute once read
+type Entry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Algo    string
+	Hash    string
+}
+
+//Struct for the result of checking frames against a manifest, contains the following:
+//-Ok is the frames that match
+//-Missing is frames in the manifest that are not on disk
+//-Extra is frames of the sequence on disk that are not in the manifest
+//-Changed is frames whose size or checksum is different
+//-Zero is frames that are 0 bytes on disk
+type Report struct {
+	Ok      []string
+	Missing []string
+	Extra   []string
+	Changed []string
+	Zero    []string
+}
+
+//Test if a report found nothing wrong
+func (r Report) Clean() bool {
+	return len(r.Missing)+len(r.Extra)+len(r.Changed)+len(r.Zero) == 0
+}
+
+//Return the format of a manifest file from its extension, sha256 unless .mhl
+func FormatOf(pth string) string {
+	if strings.EqualFold(filepath.Ext(pth), ".mhl") {
+		return Mhl
+	}
+	return Sha256
+}
+
+//Return the format of a manifest file from its content, a manifest may have been
+//written with -manifestformat mhl to a path without the .mhl extension
+func formatOfContent(pth string) (string, error) {
+	f, open_err := os.Open(pth)
+	if open_err != nil {
+		return "", open_err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, read_err := io.ReadFull(f, head)
+	if read_err != nil && read_err != io.ErrUnexpectedEOF && read_err != io.EOF {
+		return "", read_err
+	}
+	start := strings.TrimLeft(strings.TrimPrefix(string(head[:n]), "\ufeff"), " \t\r\n")
+	if strings.HasPrefix(start, "<?xml") || strings.HasPrefix(start, "<hashlist") {
+		return Mhl, nil
+	}
+	return Sha256, nil
+}
+
+//Hash files and write them to a manifest at pth in format, title is written
+//as a comment at the top ie: the listing of the sequence.  An existing manifest
+//at pth is only overwritten with force
+func Create(files []string, pth string, format string, title string, force bool) ([]Entry, error) {
+	var entries []Entry
+	algo := "sha256"
+	if format == Mhl {
+		algo = "md5"
+	} else if format != Sha256 {
+		return entries, fmt.Errorf("Manifest format must be %s or %s, not %s", Sha256, Mhl, format)
+	}
+	if _, stat_err := os.Lstat(pth); stat_err == nil && !force {
+		return entries, errors.New(pth + " already exists\nUse -f to overwrite it")
+	}
+	for _, x := range files {
+		entry, hash_err := HashEntry(x, algo)
+		if hash_err != nil {
+			return entries, hash_err
+		}
+		entries = append(entries, entry)
+	}
+	if format == Mhl {
+		return entries, writeMhl(entries, pth)
+	}
+	return entries, writeSha256(entries, pth, title)
+}
+
+//Return the manifest entry of a file hashed with algo: sha256, sha1 or md5
+func HashEntry(pth string, algo string) (Entry, error) {
+	abs, abs_err := filepath.Abs(pth)
+	if abs_err != nil {
+		return Entry{}, abs_err
+	}
+	f, open_err := os.Open(abs)
+	if open_err != nil {
+		return Entry{}, open_err
+	}
+	defer f.Close()
+	fi, stat_err := f.Stat()
+	if stat_err != nil {
+		return Entry{}, stat_err
+	}
+	h, algo_err := newHash(algo)
+	if algo_err != nil {
+		return Entry{}, algo_err
+	}
+	if _, read_err := io.Copy(h, f); read_err != nil {
+		return Entry{}, fmt.Errorf("Unable to read %s - %v", abs, read_err)
+	}
+	entry := Entry{
+		Path:    abs,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UTC(),
+		Algo:    algo,
+		Hash:    hex.EncodeToString(h.Sum(nil)),
+	}
+	return entry, nil
+}
+
+//Read a manifest in either format, paths are made absolute from the directory
+//of the manifest
+func Read(pth string) ([]Entry, error) {
+	var entries []Entry
+	format, read_err := formatOfContent(pth)
+	if read_err != nil {
+		return entries, read_err
+	}
+	if format == Mhl {
+		entries, read_err = readMhl(pth)
+	} else {
+		entries, read_err = readSha256(pth)
+	}
+	if read_err != nil {
+		return entries, read_err
+	}
+	if len(entries) == 0 {
+		return entries, errors.New(pth + " has no frames")
+	}
+	dir, abs_err := filepath.Abs(filepath.Dir(pth))
+	if abs_err != nil {
+		return entries, abs_err
+	}
+	for i, e := range entries {
+		if !filepath.IsAbs(e.Path) {
+			entries[i].Path = filepath.Join(dir, filepath.FromSlash(e.Path))
+		}
+	}
+	return entries, nil
+}
+
+//Check the frames of a manifest on disk.  Frames are changed when their size
+//or checksum differ, a new modification time alone is not a change.  Extra
+//frames are not known to the manifest, see Report.Extra
+func Verify(entries []Entry) (Report, error) {
+	var report Report
+	for _, e := range entries {
+		fi, stat_err := os.Stat(e.Path)
+		if os.IsNotExist(stat_err) {
+			report.Missing = append(report.Missing, e.Path)
+			continue
+		}
+		if stat_err != nil {
+			return report, stat_err
+		}
+		if fi.Size() == 0 {
+			report.Zero = append(report.Zero, e.Path)
+		}
+		if e.Size >= 0 && fi.Size() != e.Size {
+			report.Changed = append(report.Changed, e.Path)
+			continue
+		}
+		current, hash_err := HashEntry(e.Path, e.Algo)
+		if hash_err != nil {
+			return report, hash_err
+		}
+		if !strings.EqualFold(current.Hash, e.Hash) {
+			report.Changed = append(report.Changed, e.Path)
+			continue
+		}
+		if fi.Size() != 0 {
+			report.Ok = append(report.Ok, e.Path)
+		}
+	}
+	return report, nil
+}
+
+//Return a new hash for an algorithm name
+func newHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "md5":
+		return md5.New(), nil
+	}
+	return nil, fmt.Errorf("Unsupported hash %s, use sha256, sha1 or md5", algo)
+}
+
+//Return the path of a frame relative to the directory of the manifest, with
+//forward slashes
+func relPath(pth string, manifest_pth string) string {
+	dir, abs_err := filepath.Abs(filepath.Dir(manifest_pth))
+	if abs_err != nil {
+		return pth
+	}
+	rel, rel_err := filepath.Rel(dir, pth)
+	if rel_err != nil {
+		return pth
+	}
+	return filepath.ToSlash(rel)
+}
+
+//Write entries in the format of sha256sum ie:
+//# size 1048576 mtime 2026-01-02T15:04:05.123456789Z
+//e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  plate.1001.exr
+func writeSha256(entries []Entry, pth string, title string) error {
+	f, create_err := os.Create(pth)
+	if create_err != nil {
+		return create_err
+	}
+	writer := bufio.NewWriter(f)
+	fmt.Fprintf(writer, "# fileseq manifest of %s\n", title)
+	fmt.Fprintf(writer, "# created %s, check with: sha256sum -c %s\n", time.Now().UTC().Format(time.RFC3339), filepath.Base(pth))
+	for _, e := range entries {
+		fmt.Fprintf(writer, "# size %d mtime %s\n", e.Size, e.ModTime.Format(time.RFC3339Nano))
+		fmt.Fprintf(writer, "%s  %s\n", e.Hash, relPath(e.Path, pth))
+	}
+	if flush_err := writer.Flush(); flush_err != nil {
+		f.Close()
+		return flush_err
+	}
+	return f.Close()
+}
+
+//Read a sha256sum file, the size and mtime comments are optional.  Entries
+//without a size comment have a Size of -1 and are only checked by hash
+func readSha256(pth string) ([]Entry, error) {
+	var entries []Entry
+	f, open_err := os.Open(pth)
+	if open_err != nil {
+		return entries, open_err
+	}
+	defer f.Close()
+
+	size := int64(-1)
+	var mtime time.Time
+	scanner := bufio.NewScanner(f)
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(strings.TrimPrefix(line, "#"))
+			if len(fields) == 4 && fields[0] == "size" && fields[2] == "mtime" {
+				size, _ = strconv.ParseInt(fields[1], 10, 64)
+				mtime, _ = time.Parse(time.RFC3339Nano, fields[3])
+			}
+			continue
+		}
+		//Lines are "<hash>  <path>", or "<hash> *<path>" for binary mode
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 || len(parts[0]) != sha256.Size*2 || len(parts[1]) < 2 {
+			return entries, fmt.Errorf("%s line %d is not a sha256sum line", pth, line_num)
+		}
+		entries = append(entries, Entry{Path: parts[1][1:], Size: size, ModTime: mtime, Algo: "sha256", Hash: parts[0]})
+		size = -1
+		mtime = time.Time{}
+	}
+	return entries, scanner.Err()
+}
+
+//Structs for the xml of an ASC MHL file
+type mhlHashList struct {
+	XMLName xml.Name       `xml:"hashlist"`
+	Version string         `xml:"version,attr"`
+	Creator mhlCreatorInfo `xml:"creatorinfo"`
+	Hashes  []mhlHash      `xml:"hash"`
+}
+
+type mhlCreatorInfo struct {
+	Username   string `xml:"username"`
+	Hostname   string `xml:"hostname"`
+	Tool       string `xml:"tool"`
+	StartDate  string `xml:"startdate"`
+	FinishDate string `xml:"finishdate"`
+}
+
+type mhlHash struct {
+	File    string `xml:"file"`
+	Size    int64  `xml:"size"`
+	ModDate string `xml:"lastmodificationdate"`
+	Md5     string `xml:"md5,omitempty"`
+	Sha1    string `xml:"sha1,omitempty"`
+	Sha256  string `xml:"sha256,omitempty"`
+	Date    string `xml:"hashdate"`
+}
+
+//Write entries as an ASC MHL version 1.1 file
+func writeMhl(entries []Entry, pth string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	hostname, _ := os.Hostname()
+	list := mhlHashList{
+		Version: "1.1",
+		Creator: mhlCreatorInfo{
+			Username:   os.Getenv("USER"),
+			Hostname:   hostname,
+			Tool:       "fileseq",
+			StartDate:  now,
+			FinishDate: now,
+		},
+	}
+	for _, e := range entries {
+		h := mhlHash{
+			File:    relPath(e.Path, pth),
+			Size:    e.Size,
+			ModDate: e.ModTime.Format(time.RFC3339),
+			Date:    now,
+		}
+		switch e.Algo {
+		case "md5":
+			h.Md5 = e.Hash
+		case "sha1":
+			h.Sha1 = e.Hash
+		default:
+			h.Sha256 = e.Hash
+		}
+		list.Hashes = append(list.Hashes, h)
+	}
+	out, xml_err := xml.MarshalIndent(list, "", "  ")
+	if xml_err != nil {
+		return xml_err
+	}
+	return ioutil.WriteFile(pth, append([]byte(xml.Header), append(out, '\n')...), 0666)
+}
+
+//Read an ASC MHL file, each frame uses the first of md5, sha1 or sha256 it has
+func readMhl(pth string) ([]Entry, error) {
+	var entries []Entry
+	data, read_err := ioutil.ReadFile(pth)
+	if read_err != nil {
+		return entries, read_err
+	}
+	var list mhlHashList
+	if xml_err := xml.Unmarshal(data, &list); xml_err != nil {
+		return entries, fmt.Errorf("%s is not an MHL file - %v", pth, xml_err)
+	}
+	for _, h := range list.Hashes {
+		mtime, _ := time.Parse(time.RFC3339, h.ModDate)
+		e := Entry{Path: h.File, Size: h.Size, ModTime: mtime}
+		switch {
+		case h.Md5 != "":
+			e.Algo, e.Hash = "md5", h.Md5
+		case h.Sha1 != "":
+			e.Algo, e.Hash = "sha1", h.Sha1
+		case h.Sha256 != "":
+			e.Algo, e.Hash = "sha256", h.Sha256
+		default:
+			return entries, fmt.Errorf("%s has no md5, sha1 or sha256 hash for %s", pth, h.File)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
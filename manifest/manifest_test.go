@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//Write the frames of a test sequence under dir and return their paths
+func writeFrames(t *testing.T, dir string) []string {
+	var files []string
+	for i, name := range []string{"plate.1001.exr", "plate.1002.exr", "sub/plate.1003.exr", "with space.1004.exr", "empty.1005.exr"} {
+		pth := filepath.Join(dir, filepath.FromSlash(name))
+		if mk_err := os.MkdirAll(filepath.Dir(pth), 0755); mk_err != nil {
+			t.Fatal(mk_err)
+		}
+		data := make([]byte, i*1000)
+		for j := range data {
+			data[j] = byte(i + j)
+		}
+		if write_err := ioutil.WriteFile(pth, data, 0644); write_err != nil {
+			t.Fatal(write_err)
+		}
+		files = append(files, pth)
+	}
+	return files
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		algo   string
+		mtime  time.Duration
+	}{
+		{"sha256", Sha256, "plate.sha256", "sha256", 0},
+		{"mhl", Mhl, "plate.mhl", "md5", time.Second},
+		{"mhl without extension", Mhl, "plate.txt", "md5", time.Second},
+		{"sha256 named mhl", Sha256, "sums/plate.mhl", "sha256", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := writeFrames(t, dir)
+			pth := filepath.Join(dir, filepath.FromSlash(tt.file))
+			if mk_err := os.MkdirAll(filepath.Dir(pth), 0755); mk_err != nil {
+				t.Fatal(mk_err)
+			}
+			created, create_err := Create(files, pth, tt.format, "plate.[1001-1005].exr", false)
+			if create_err != nil {
+				t.Fatalf("Create() error = %v", create_err)
+			}
+			entries, read_err := Read(pth)
+			if read_err != nil {
+				t.Fatalf("Read() error = %v", read_err)
+			}
+			if len(entries) != len(created) {
+				t.Fatalf("Read() = %d entries, want %d", len(entries), len(created))
+			}
+			for i, e := range entries {
+				want := created[i]
+				if e.Path != want.Path || e.Size != want.Size || e.Algo != tt.algo || e.Hash != want.Hash {
+					t.Errorf("Read() entry %d = %+v, want %+v", i, e, want)
+				}
+				if !e.ModTime.Equal(want.ModTime.Truncate(tt.mtime)) {
+					t.Errorf("Read() %s mtime = %v, want %v", e.Path, e.ModTime, want.ModTime)
+				}
+			}
+
+			report, verify_err := Verify(entries)
+			if verify_err != nil {
+				t.Fatalf("Verify() error = %v", verify_err)
+			}
+			if len(report.Ok) != len(files)-1 || len(report.Zero) != 1 || len(report.Missing)+len(report.Changed) != 0 {
+				t.Errorf("Verify() of unchanged frames = %+v", report)
+			}
+
+			if write_err := ioutil.WriteFile(files[1], []byte("re-rendered"), 0644); write_err != nil {
+				t.Fatal(write_err)
+			}
+			if rm_err := os.Remove(files[2]); rm_err != nil {
+				t.Fatal(rm_err)
+			}
+			report, verify_err = Verify(entries)
+			if verify_err != nil {
+				t.Fatalf("Verify() error = %v", verify_err)
+			}
+			if len(report.Changed) != 1 || report.Changed[0] != files[1] {
+				t.Errorf("Verify() changed = %v, want %s", report.Changed, files[1])
+			}
+			if len(report.Missing) != 1 || report.Missing[0] != files[2] {
+				t.Errorf("Verify() missing = %v, want %s", report.Missing, files[2])
+			}
+		})
+	}
+}
+
+//An existing manifest is only overwritten with force
+func TestCreateExisting(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFrames(t, dir)
+	pth := filepath.Join(dir, "plate.sha256")
+	if write_err := ioutil.WriteFile(pth, []byte("keep\n"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	if _, create_err := Create(files, pth, Sha256, "plate.[1001-1005].exr", false); create_err == nil {
+		t.Error("Create() of an existing manifest without force did not fail")
+	}
+	if data, _ := ioutil.ReadFile(pth); string(data) != "keep\n" {
+		t.Errorf("Create() without force changed the manifest to %q", data)
+	}
+	if _, create_err := Create(files, pth, Sha256, "plate.[1001-1005].exr", true); create_err != nil {
+		t.Fatalf("Create() with force error = %v", create_err)
+	}
+	if entries, read_err := Read(pth); read_err != nil || len(entries) != len(files) {
+		t.Errorf("Read() after Create() with force = %d entries, %v, want %d", len(entries), read_err, len(files))
+	}
+}
+
+func TestReadNotManifest(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "notes.txt")
+	if write_err := ioutil.WriteFile(pth, []byte("not a checksum line\n"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	if _, read_err := Read(pth); read_err == nil {
+		t.Error("Read() of a file that is not a manifest did not fail")
+	}
+}
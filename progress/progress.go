@@ -0,0 +1,188 @@
+//Package progress displays the progress of copy, move, delete and fill
+//operations.  On a terminal it is a single line that is redrawn in place,
+//otherwise it is a json event per line that may be read by other tools
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+//How often the terminal line is redrawn and json events are written
+const (
+	TermInterval = 100 * time.Millisecond
+	JsonInterval = time.Second
+)
+
+//Struct for the progress of one operation at a time, contains the following:
+//-Out is where the progress is written, usually os.Stderr
+//-Json writes events instead of a terminal line
+//-Interval is the least time between updates, the first and last are always written
+type Printer struct {
+	Out      io.Writer
+	Json     bool
+	Interval time.Duration
+
+	op         string
+	files      int
+	bytes      int64
+	done_files int
+	done_bytes int64
+	started    time.Time
+	last       time.Time
+}
+
+//Struct for a json progress event, Event is one of start, progress or done.
+//Eta is -1 until the first file is done
+type Event struct {
+	Event       string  `json:"event"`
+	Op          string  `json:"op"`
+	File        string  `json:"file,omitempty"`
+	Files       int     `json:"files"`
+	FilesDone   int     `json:"files_done"`
+	Bytes       int64   `json:"bytes"`
+	BytesDone   int64   `json:"bytes_done"`
+	BytesPerSec float64 `json:"bytes_per_sec"`
+	Elapsed     float64 `json:"elapsed_sec"`
+	Eta         float64 `json:"eta_sec"`
+	Error       string  `json:"error,omitempty"`
+}
+
+//Create a Printer writing to out, json events are used when out is not a
+//terminal
+func New(out *os.File) *Printer {
+	if IsTerminal(out) {
+		return &Printer{Out: out, Interval: TermInterval}
+	}
+	return &Printer{Out: out, Json: true, Interval: JsonInterval}
+}
+
+//Test if a file is a terminal
+func IsTerminal(f *os.File) bool {
+	fi, stat_err := f.Stat()
+	if stat_err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+//Begin the progress of an operation, see seq_manip.Progress
+func (p *Printer) Start(op string, files int, bytes int64) {
+	p.op = op
+	p.files = files
+	p.bytes = bytes
+	p.done_files = 0
+	p.done_bytes = 0
+	p.started = time.Now()
+	p.last = p.started
+	if p.Json {
+		p.writeEvent("start", "", nil)
+		return
+	}
+	p.writeLine()
+}
+
+//Count a file that is done, the display is only written once per Interval
+func (p *Printer) Update(file string, bytes int64) {
+	p.done_files++
+	p.done_bytes += bytes
+	now := time.Now()
+	if now.Sub(p.last) < p.Interval && p.done_files < p.files {
+		return
+	}
+	p.last = now
+	if p.Json {
+		p.writeEvent("progress", file, nil)
+		return
+	}
+	p.writeLine()
+}
+
+//Write the final progress of an operation and its error, if any
+func (p *Printer) Finish(err error) {
+	if p.Json {
+		p.writeEvent("done", "", err)
+		return
+	}
+	p.writeLine()
+	fmt.Fprintln(p.Out, "")
+}
+
+//Return the bytes per second, and the seconds left.  The rate of files is
+//used for the estimate when there are no bytes, ie: empty files
+func (p *Printer) rate() (float64, float64) {
+	elapsed := time.Since(p.started).Seconds()
+	if elapsed <= 0 || p.done_files == 0 {
+		return 0, -1
+	}
+	bytes_per_sec := float64(p.done_bytes) / elapsed
+	if p.done_bytes > 0 {
+		return bytes_per_sec, float64(p.bytes-p.done_bytes) / bytes_per_sec
+	}
+	files_per_sec := float64(p.done_files) / elapsed
+	return bytes_per_sec, float64(p.files-p.done_files) / files_per_sec
+}
+
+//Redraw the terminal line ie:
+//copy   45/100 files  1.2 GB/2.4 GB  85.3 MB/s  ETA 0:14
+func (p *Printer) writeLine() {
+	bytes_per_sec, eta := p.rate()
+	eta_str := "--:--"
+	if eta >= 0 {
+		eta_str = FormatDuration(eta)
+	}
+	fmt.Fprintf(p.Out, "\r%s %*d/%d files  %s/%s  %s/s  ETA %s\033[K", p.op, len(fmt.Sprint(p.files)), p.done_files, p.files,
+		FormatBytes(p.done_bytes), FormatBytes(p.bytes), FormatBytes(int64(bytes_per_sec)), eta_str)
+}
+
+//Write a json event on its own line
+func (p *Printer) writeEvent(event string, file string, err error) {
+	bytes_per_sec, eta := p.rate()
+	e := Event{
+		Event:       event,
+		Op:          p.op,
+		File:        file,
+		Files:       p.files,
+		FilesDone:   p.done_files,
+		Bytes:       p.bytes,
+		BytesDone:   p.done_bytes,
+		BytesPerSec: bytes_per_sec,
+		Elapsed:     time.Since(p.started).Seconds(),
+		Eta:         eta,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	line, json_err := json.Marshal(e)
+	if json_err != nil {
+		return
+	}
+	fmt.Fprintln(p.Out, string(line))
+}
+
+//Format a number of bytes ie: 1.2 GB
+func FormatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	size := float64(n)
+	i := 0
+	for size >= 1000 && i < len(units)-1 {
+		size /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
+
+//Format seconds as m:ss, or h:mm:ss when over an hour
+func FormatDuration(secs float64) string {
+	s := int(secs + 0.5)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,119 @@
+package reducers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Function to render a File_seq as the path an application reads it with.  It
+//is only given sequences, files that are not a sequence are used as they are
+type Formatter func(fs File_seq) string
+
+//Formatters by name, see RegisterFormatter
+var formatters = map[string]Formatter{
+	"native":  func(fs File_seq) string { return fs.F_seq },
+	"nuke":    nukeFormat,
+	"houdini": houdiniFormat,
+	"maya":    hashFormat,
+	"blender": hashFormat,
+	"rv":      rvFormat,
+}
+
+//Add a formatter, or replace the formatter of the same name
+func RegisterFormatter(name string, f Formatter) {
+	formatters[strings.ToLower(name)] = f
+}
+
+//Return the names of the formatters in order
+func FormatterNames() []string {
+	var names []string
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Return the listing of a File_seq rendered by the formatter name ie: nuke
+func (fs File_seq) Format(name string) (string, error) {
+	f, ok := formatters[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("Unknown format %s, use one of %s", name, strings.Join(FormatterNames(), ", "))
+	}
+	if !strings.Contains(fs.Base, `@`) || len(fs.File_list) == 0 {
+		return fs.F_seq, nil
+	}
+	return f(fs), nil
+}
+
+//Return the padding of a File_seq, the width of the frame numbers when any of
+//them have leading zeros or they all have the same width ie: 4 for 1001-1100,
+//otherwise 0 for unpadded frame numbers ie: 8-12
+func (fs File_seq) Padding() int {
+	width := -1
+	for _, f := range fs.File_list {
+		num := fs.File_num[f]
+		if len(num) > 1 && strings.HasPrefix(num, "0") {
+			return len(num)
+		}
+		if width != -1 && len(num) != width {
+			width = 0
+		} else if width == -1 {
+			width = len(num)
+		}
+	}
+	if width <= 1 {
+		return 0
+	}
+	return width
+}
+
+//Return the first through last frame ie: 1001-1100, or one frame ie: 1001
+func (fs File_seq) frameSpan() string {
+	first, last := fs.File_list[0], fs.File_list[len(fs.File_list)-1]
+	if first == last {
+		return fmt.Sprintf("%d", first)
+	}
+	return fmt.Sprintf("%d-%d", first, last)
+}
+
+//Nuke ie: file.%04d.exr 1001-1100
+func nukeFormat(fs File_seq) string {
+	frame := "%d"
+	if pad := fs.Padding(); pad > 0 {
+		frame = fmt.Sprintf("%%0%dd", pad)
+	}
+	return strings.Replace(fs.Base, `@`, frame, 1) + " " + fs.frameSpan()
+}
+
+//Houdini ie: file.$F4.exr
+func houdiniFormat(fs File_seq) string {
+	frame := "$F"
+	if pad := fs.Padding(); pad > 0 {
+		frame = fmt.Sprintf("$F%d", pad)
+	}
+	return strings.Replace(fs.Base, `@`, frame, 1)
+}
+
+//Maya and Blender ie: file.####.exr, one '#' for each digit of padding
+func hashFormat(fs File_seq) string {
+	pad := fs.Padding()
+	if pad == 0 {
+		pad = 1
+	}
+	return strings.Replace(fs.Base, `@`, strings.Repeat("#", pad), 1)
+}
+
+//RV ie: file.1001-1100#.exr.  RV reads '#' as 4 digits and '@' as one digit,
+//so other paddings use an '@' for each digit
+func rvFormat(fs File_seq) string {
+	frame := "@"
+	switch pad := fs.Padding(); {
+	case pad == 4:
+		frame = "#"
+	case pad > 0:
+		frame = strings.Repeat("@", pad)
+	}
+	return strings.Replace(fs.Base, `@`, fs.frameSpan()+frame, 1)
+}
@@ -0,0 +1,110 @@
+package reducers
+
+import (
+	"strconv"
+	"testing"
+)
+
+//Return a File_seq of base with the frame numbers nums, as they are on disk
+func seqOf(base string, nums ...string) File_seq {
+	fs := File_seq{Base: base, F_seq: base, File_num: make(map[int]string)}
+	for _, num := range nums {
+		f, _ := strconv.Atoi(num)
+		fs.File_num[f] = num
+		fs.File_list = append(fs.File_list, f)
+	}
+	return fs
+}
+
+func TestPadding(t *testing.T) {
+	tests := []struct {
+		name string
+		nums []string
+		want int
+	}{
+		{"padded", []string{"0001", "0002"}, 4},
+		{"same width", []string{"1001", "1100"}, 4},
+		{"padded past its width", []string{"0999", "1000"}, 4},
+		{"mixed width", []string{"8", "9", "10"}, 0},
+		{"one digit", []string{"1", "2"}, 0},
+		{"one frame", []string{"1001"}, 4},
+		{"zero", []string{"0"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seqOf("plate.@.exr", tt.nums...).Padding(); got != tt.want {
+				t.Errorf("Padding() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	padded := seqOf("/shots/plate.@.exr", "0001", "0002", "0100")
+	wide := seqOf("/shots/plate.@.exr", "1001", "1002", "1100")
+	unpadded := seqOf("/shots/plate.@.exr", "8", "9", "10")
+	six := seqOf("/shots/plate.@.exr", "000001", "000002")
+	one := seqOf("/shots/plate.@.exr", "1001")
+	one_padded := seqOf("/shots/plate.@.exr", "0001")
+	tests := []struct {
+		format string
+		fs     File_seq
+		want   string
+	}{
+		{"nuke", padded, "/shots/plate.%04d.exr 1-100"},
+		{"nuke", wide, "/shots/plate.%04d.exr 1001-1100"},
+		{"nuke", unpadded, "/shots/plate.%d.exr 8-10"},
+		{"nuke", one_padded, "/shots/plate.%04d.exr 1"},
+		{"nuke", one, "/shots/plate.%04d.exr 1001"},
+		{"Nuke", six, "/shots/plate.%06d.exr 1-2"},
+		{"houdini", padded, "/shots/plate.$F4.exr"},
+		{"houdini", wide, "/shots/plate.$F4.exr"},
+		{"houdini", unpadded, "/shots/plate.$F.exr"},
+		{"houdini", six, "/shots/plate.$F6.exr"},
+		{"maya", padded, "/shots/plate.####.exr"},
+		{"maya", wide, "/shots/plate.####.exr"},
+		{"maya", unpadded, "/shots/plate.#.exr"},
+		{"blender", padded, "/shots/plate.####.exr"},
+		{"blender", wide, "/shots/plate.####.exr"},
+		{"blender", six, "/shots/plate.######.exr"},
+		{"rv", padded, "/shots/plate.1-100#.exr"},
+		{"rv", wide, "/shots/plate.1001-1100#.exr"},
+		{"rv", unpadded, "/shots/plate.8-10@.exr"},
+		{"rv", six, "/shots/plate.1-2@@@@@@.exr"},
+		{"rv", one_padded, "/shots/plate.1#.exr"},
+		{"rv", one, "/shots/plate.1001#.exr"},
+		{"native", padded, "/shots/plate.@.exr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.want, func(t *testing.T) {
+			got, format_err := tt.fs.Format(tt.format)
+			if format_err != nil {
+				t.Fatalf("Format(%q) error = %v", tt.format, format_err)
+			}
+			if got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+//Files that are not a sequence are not formatted
+func TestFormatNotASequence(t *testing.T) {
+	fs := File_seq{Base: "/shots/notes.txt", F_seq: "/shots/notes.txt"}
+	for _, name := range FormatterNames() {
+		if got, _ := fs.Format(name); got != "/shots/notes.txt" {
+			t.Errorf("Format(%q) of a file = %q", name, got)
+		}
+	}
+	if _, format_err := fs.Format("flame"); format_err == nil {
+		t.Error("Format() of an unknown format did not fail")
+	}
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	RegisterFormatter("Upper", func(fs File_seq) string { return "upper " + fs.Base })
+	defer delete(formatters, "upper")
+	if got, _ := seqOf("plate.@.exr", "1").Format("upper"); got != "upper plate.@.exr" {
+		t.Errorf("Format() of a registered formatter = %q", got)
+	}
+}
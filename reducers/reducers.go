@@ -32,7 +32,6 @@ type File_seq struct {
 
 //Function to take listing of files and create the base and file list
 func ReduceBase(files []string) (map[string]map[int]string, error) {
-	fmt.Println()
 	bases := make(map[string]map[int]string)
 
 	seq_def, err := seq_definition.SeqDefinition()
@@ -0,0 +1,137 @@
+//Package seq_diff compares sequences, ie a vendor redelivery against the first
+//delivery, and reports the sequences and frames that changed
+package seq_diff
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/manifest"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Struct for the difference of one sequence, contains the following:
+//-Sequence is the listing, relative to the directory when trees are compared
+//-Status is added, removed, changed or same
+//-Old and New are the listings that were compared
+//-Gained and Lost are the frames only in New or only in Old ie: "1001-1010,1020"
+//-Changed is the frames in both whose size, or checksum, differs
+type SeqDiff struct {
+	Sequence string `json:"sequence"`
+	Status   string `json:"status"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Gained   string `json:"gained,omitempty"`
+	Lost     string `json:"lost,omitempty"`
+	Changed  string `json:"changed,omitempty"`
+}
+
+//Compare two sequences frame by frame.  Frames in both are compared by size,
+//and by sha256 checksum as well when checksum is set
+func Compare(old reducers.File_seq, new reducers.File_seq, checksum bool) (SeqDiff, error) {
+	d := SeqDiff{Sequence: new.F_seq, Status: "same", Old: old.F_seq, New: new.F_seq}
+	var gained, lost, changed []int
+	for _, f := range new.File_list {
+		if _, ok := old.File_num[f]; !ok {
+			gained = append(gained, f)
+		}
+	}
+	for _, f := range old.File_list {
+		if _, ok := new.File_num[f]; !ok {
+			lost = append(lost, f)
+			continue
+		}
+		same, cmp_err := sameFile(frameFile(old, f), frameFile(new, f), checksum)
+		if cmp_err != nil {
+			return d, cmp_err
+		}
+		if !same {
+			changed = append(changed, f)
+		}
+	}
+	d.Gained = expanders.Frame_range_string(gained)
+	d.Lost = expanders.Frame_range_string(lost)
+	d.Changed = expanders.Frame_range_string(changed)
+	if len(gained)+len(lost)+len(changed) > 0 {
+		d.Status = "changed"
+	}
+	return d, nil
+}
+
+//Compare the sequences of two directory trees, sequences are matched by their
+//path relative to old_root and new_root.  Returns a SeqDiff for every sequence
+//of either tree, ordered by path
+func CompareTrees(old []reducers.File_seq, old_root string, new []reducers.File_seq, new_root string, checksum bool) ([]SeqDiff, error) {
+	var diffs []SeqDiff
+	old_by_key := make(map[string]reducers.File_seq)
+	for _, x := range old {
+		old_by_key[relPath(old_root, x.Base)] = x
+	}
+	seen := make(map[string]bool)
+	for _, x := range new {
+		key := relPath(new_root, x.Base)
+		seen[key] = true
+		prev, ok := old_by_key[key]
+		if !ok {
+			diffs = append(diffs, SeqDiff{Sequence: relPath(new_root, x.F_seq), Status: "added", New: x.F_seq})
+			continue
+		}
+		d, cmp_err := Compare(prev, x, checksum)
+		if cmp_err != nil {
+			return diffs, cmp_err
+		}
+		d.Sequence = relPath(new_root, x.F_seq)
+		diffs = append(diffs, d)
+	}
+	for key, x := range old_by_key {
+		if !seen[key] {
+			diffs = append(diffs, SeqDiff{Sequence: relPath(old_root, x.F_seq), Status: "removed", Old: x.F_seq})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Sequence < diffs[j].Sequence })
+	return diffs, nil
+}
+
+//Return the file of a frame, files that are not a sequence have no '@'
+func frameFile(fs reducers.File_seq, frame int) string {
+	return strings.Replace(fs.Base, `@`, fs.File_num[frame], 1)
+}
+
+//Test if two files are the same by size, and by checksum when checksum is set
+func sameFile(a string, b string, checksum bool) (bool, error) {
+	a_info, a_err := os.Stat(a)
+	if a_err != nil {
+		return false, a_err
+	}
+	b_info, b_err := os.Stat(b)
+	if b_err != nil {
+		return false, b_err
+	}
+	if a_info.Size() != b_info.Size() {
+		return false, nil
+	}
+	if !checksum {
+		return true, nil
+	}
+	a_entry, hash_err := manifest.HashEntry(a, "sha256")
+	if hash_err != nil {
+		return false, hash_err
+	}
+	b_entry, hash_err := manifest.HashEntry(b, "sha256")
+	if hash_err != nil {
+		return false, hash_err
+	}
+	return a_entry.Hash == b_entry.Hash, nil
+}
+
+//Return pth relative to root with forward slashes, or pth if it is not under root
+func relPath(root string, pth string) string {
+	rel, rel_err := filepath.Rel(root, pth)
+	if rel_err != nil || strings.HasPrefix(rel, "..") {
+		return pth
+	}
+	return filepath.ToSlash(rel)
+}
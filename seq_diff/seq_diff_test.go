@@ -0,0 +1,142 @@
+package seq_diff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Write frames first to last of pth, a plate.@.exr pattern, holding data
+func writeFrames(t *testing.T, pth string, first int, last int, data string) {
+	if mk_err := os.MkdirAll(filepath.Dir(pth), 0755); mk_err != nil {
+		t.Fatal(mk_err)
+	}
+	for f := first; f <= last; f++ {
+		frame := fmt.Sprintf(pth, f)
+		if write_err := ioutil.WriteFile(frame, []byte(data), 0644); write_err != nil {
+			t.Fatal(write_err)
+		}
+	}
+}
+
+func listing(t *testing.T, fs string) reducers.File_seq {
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	return fseq
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old", "plate.%04d.exr")
+	new := filepath.Join(dir, "new", "plate.%04d.exr")
+	writeFrames(t, old, 1001, 1010, "aaaa")
+	writeFrames(t, new, 1003, 1012, "aaaa")
+	//1005 changes size, 1006 changes only its contents
+	writeFrames(t, new, 1005, 1005, "aaaaaa")
+	writeFrames(t, new, 1006, 1006, "bbbb")
+
+	tests := []struct {
+		name     string
+		checksum bool
+		changed  string
+	}{
+		{"by size", false, "1005"},
+		{"by checksum", true, "1005-1006"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old_seq := listing(t, filepath.Join(dir, "old", "plate.[1001-1010].exr"))
+			new_seq := listing(t, filepath.Join(dir, "new", "plate.[1003-1012].exr"))
+			d, cmp_err := Compare(old_seq, new_seq, tt.checksum)
+			if cmp_err != nil {
+				t.Fatalf("Compare() error = %v", cmp_err)
+			}
+			want := SeqDiff{Sequence: new_seq.F_seq, Status: "changed", Old: old_seq.F_seq, New: new_seq.F_seq,
+				Gained: "1011-1012", Lost: "1001-1002", Changed: tt.changed}
+			if d != want {
+				t.Errorf("Compare() = %+v, want %+v", d, want)
+			}
+		})
+	}
+}
+
+func TestCompareSame(t *testing.T) {
+	dir := t.TempDir()
+	writeFrames(t, filepath.Join(dir, "old", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	writeFrames(t, filepath.Join(dir, "new", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	old_seq := listing(t, filepath.Join(dir, "old", "plate.[1001-1005].exr"))
+	new_seq := listing(t, filepath.Join(dir, "new", "plate.[1001-1005].exr"))
+	d, cmp_err := Compare(old_seq, new_seq, true)
+	if cmp_err != nil {
+		t.Fatalf("Compare() error = %v", cmp_err)
+	}
+	if d.Status != "same" || d.Gained != "" || d.Lost != "" || d.Changed != "" {
+		t.Errorf("Compare() of the same frames = %+v", d)
+	}
+}
+
+func TestCompareMissingFrame(t *testing.T) {
+	dir := t.TempDir()
+	writeFrames(t, filepath.Join(dir, "old", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	old_seq := listing(t, filepath.Join(dir, "old", "plate.[1001-1005].exr"))
+	new_seq := listing(t, filepath.Join(dir, "new", "plate.[1001-1005].exr"))
+	if _, cmp_err := Compare(old_seq, new_seq, false); cmp_err == nil {
+		t.Error("Compare() of frames that are not on disk did not fail")
+	}
+}
+
+//Return the sequences under root
+func scan(t *testing.T, root string) []reducers.File_seq {
+	files, rec_err := filesys.Recurse(root, false)
+	if rec_err != nil {
+		t.Fatal(rec_err)
+	}
+	reduced, red_err := reducers.ReduceBase(files)
+	if red_err != nil {
+		t.Fatal(red_err)
+	}
+	file_seqs, fseq_err := reducers.ReduceFileseq(reduced)
+	if fseq_err != nil {
+		t.Fatal(fseq_err)
+	}
+	return file_seqs
+}
+
+func TestCompareTrees(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old")
+	new := filepath.Join(dir, "new")
+	writeFrames(t, filepath.Join(old, "sh010", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	writeFrames(t, filepath.Join(new, "sh010", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	writeFrames(t, filepath.Join(old, "sh020", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	writeFrames(t, filepath.Join(new, "sh020", "plate.%04d.exr"), 1001, 1006, "aaaa")
+	writeFrames(t, filepath.Join(old, "sh030", "plate.%04d.exr"), 1001, 1005, "aaaa")
+	writeFrames(t, filepath.Join(new, "sh040", "plate.%04d.exr"), 1001, 1005, "aaaa")
+
+	diffs, cmp_err := CompareTrees(scan(t, old), old, scan(t, new), new, false)
+	if cmp_err != nil {
+		t.Fatalf("CompareTrees() error = %v", cmp_err)
+	}
+	var got [][3]string
+	for _, d := range diffs {
+		got = append(got, [3]string{d.Sequence, d.Status, d.Gained})
+	}
+	want := [][3]string{
+		{"sh010/plate.[1001-1005].exr", "same", ""},
+		{"sh020/plate.[1001-1006].exr", "changed", "1006"},
+		{"sh030/plate.[1001-1005].exr", "removed", ""},
+		{"sh040/plate.[1001-1005].exr", "added", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareTrees() = %v, want %v", got, want)
+	}
+}
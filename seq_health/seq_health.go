@@ -0,0 +1,145 @@
+//Package seq_health finds the frames of a sequence that failed to render or
+//copy: frames that are missing, 0 bytes, much smaller than their neighbors, or
+//can not be read.  The bad frames are listed as a frame range that may be
+//given to a render farm to render again
+package seq_health
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Struct for the limits of a check, contains the following:
+//-Ratio is how small a frame may be compared to the median size of the frames
+//around it before it is reported as truncated, ie 0.5 is half the median
+//-Window is the number of frames on each side used for the median
+type Thresholds struct {
+	Ratio  float64
+	Window int
+}
+
+//Default limits, a frame less than half the median of the 5 frames on each
+//side of it is truncated
+var DefaultThresholds = Thresholds{Ratio: 0.5, Window: 5}
+
+//Struct for the bad frames of a sequence, each is a frame range ie: "1001-1003,1010"
+//-Missing is frames between the first and last frame that do not exist
+//-Zero is frames of 0 bytes
+//-Truncated is frames smaller than Ratio of the median around them
+//-Unreadable is frames that can not be opened or read
+//-Bad is all of the above, the frames to render again
+type Report struct {
+	Sequence   string `json:"sequence"`
+	Frames     int    `json:"frames"`
+	Missing    string `json:"missing,omitempty"`
+	Zero       string `json:"zero_byte,omitempty"`
+	Truncated  string `json:"truncated,omitempty"`
+	Unreadable string `json:"unreadable,omitempty"`
+	Bad        string `json:"bad,omitempty"`
+}
+
+//Test if a report found no bad frames
+func (r Report) Healthy() bool {
+	return r.Bad == ""
+}
+
+//Check the frames of a sequence from its first to its last frame
+func Check(fs reducers.File_seq, t Thresholds) Report {
+	report := Report{Sequence: fs.F_seq}
+	if len(fs.File_list) == 0 {
+		return report
+	}
+	pad := fs.Padding()
+	first, last := fs.File_list[0], fs.File_list[len(fs.File_list)-1]
+
+	var missing, zero, truncated, unreadable, bad []int
+	var frames []int
+	sizes := make(map[int]int64)
+	for f := first; f <= last; f++ {
+		report.Frames++
+		num, ok := fs.File_num[f]
+		if !ok {
+			num = fmt.Sprintf("%0*d", pad, f)
+		}
+		size, read_err := readable(strings.Replace(fs.Base, `@`, num, 1))
+		switch {
+		case os.IsNotExist(read_err):
+			missing = append(missing, f)
+		case read_err != nil:
+			unreadable = append(unreadable, f)
+		case size == 0:
+			zero = append(zero, f)
+		default:
+			frames = append(frames, f)
+			sizes[f] = size
+		}
+	}
+
+	//Frames are compared to the median of the readable frames around them
+	for i, f := range frames {
+		var around []int64
+		for j := i - t.Window; j <= i+t.Window; j++ {
+			if j >= 0 && j < len(frames) && j != i {
+				around = append(around, sizes[frames[j]])
+			}
+		}
+		if len(around) == 0 {
+			continue
+		}
+		if float64(sizes[f]) < t.Ratio*median(around) {
+			truncated = append(truncated, f)
+		}
+	}
+
+	bad = append(bad, missing...)
+	bad = append(bad, zero...)
+	bad = append(bad, truncated...)
+	bad = append(bad, unreadable...)
+	report.Missing = expanders.Frame_range_string(missing)
+	report.Zero = expanders.Frame_range_string(zero)
+	report.Truncated = expanders.Frame_range_string(truncated)
+	report.Unreadable = expanders.Frame_range_string(unreadable)
+	report.Bad = expanders.Frame_range_string(bad)
+	return report
+}
+
+//Return the size of a file after reading its first and last bytes
+func readable(pth string) (int64, error) {
+	f, open_err := os.Open(pth)
+	if open_err != nil {
+		return 0, open_err
+	}
+	defer f.Close()
+	fi, stat_err := f.Stat()
+	if stat_err != nil {
+		return 0, stat_err
+	}
+	if fi.Size() == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, 1)
+	if _, read_err := f.ReadAt(buf, 0); read_err != nil && read_err != io.EOF {
+		return 0, read_err
+	}
+	if _, read_err := f.ReadAt(buf, fi.Size()-1); read_err != nil && read_err != io.EOF {
+		return 0, read_err
+	}
+	return fi.Size(), nil
+}
+
+//Return the median of sizes
+func median(sizes []int64) float64 {
+	sorted := append([]int64{}, sizes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
@@ -0,0 +1,96 @@
+package seq_health
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattbro2/filesequence/expanders"
+)
+
+//Lay out plate.1001-1020.exr of 100 bytes each in a temp dir, then apply each
+//change to the frame it is keyed by: "missing", "zero", "truncated" or
+//"unreadable", an unreadable frame is a directory named like a frame
+func healthFixture(t *testing.T, changes map[int]string) string {
+	dir := t.TempDir()
+	for f := 1001; f <= 1020; f++ {
+		pth := filepath.Join(dir, fmt.Sprintf("plate.%04d.exr", f))
+		size := 100
+		switch changes[f] {
+		case "missing":
+			continue
+		case "unreadable":
+			if mk_err := os.Mkdir(pth, 0755); mk_err != nil {
+				t.Fatal(mk_err)
+			}
+			continue
+		case "zero":
+			size = 0
+		case "truncated":
+			size = 30
+		}
+		if write_err := ioutil.WriteFile(pth, []byte(strings.Repeat("x", size)), 0644); write_err != nil {
+			t.Fatal(write_err)
+		}
+	}
+	return filepath.Join(dir, "plate.[1001-1020].exr")
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[int]string
+		want    Report
+	}{
+		{"healthy", nil, Report{}},
+		{"missing", map[int]string{1005: "missing", 1006: "missing"}, Report{Missing: "1005-1006", Bad: "1005-1006"}},
+		{"zero byte", map[int]string{1010: "zero"}, Report{Zero: "1010", Bad: "1010"}},
+		{"truncated", map[int]string{1003: "truncated", 1020: "truncated"}, Report{Truncated: "1003,1020", Bad: "1003,1020"}},
+		{"unreadable", map[int]string{1012: "unreadable"}, Report{Unreadable: "1012", Bad: "1012"}},
+		{"all", map[int]string{1002: "missing", 1004: "zero", 1008: "truncated", 1015: "unreadable"},
+			Report{Missing: "1002", Zero: "1004", Truncated: "1008", Unreadable: "1015", Bad: "1002,1004,1008,1015"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fseq, fs_err := expanders.Fseq_to_object(healthFixture(t, tt.changes))
+			if fs_err != nil {
+				t.Fatal(fs_err)
+			}
+			got := Check(fseq, DefaultThresholds)
+			tt.want.Sequence = fseq.F_seq
+			tt.want.Frames = 20
+			if got != tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+			if got.Healthy() != (tt.want.Bad == "") {
+				t.Errorf("Healthy() = %v with bad frames %q", got.Healthy(), got.Bad)
+			}
+		})
+	}
+}
+
+//Frames are only truncated when smaller than Ratio of the median around them
+func TestCheckThresholds(t *testing.T) {
+	fseq, fs_err := expanders.Fseq_to_object(healthFixture(t, map[int]string{1010: "truncated"}))
+	if fs_err != nil {
+		t.Fatal(fs_err)
+	}
+	if got := Check(fseq, Thresholds{Ratio: 0.2, Window: 5}); got.Truncated != "" {
+		t.Errorf("Check() at a ratio of 0.2 found %q truncated, want none", got.Truncated)
+	}
+	if got := Check(fseq, Thresholds{Ratio: 0.5, Window: 1}); got.Truncated != "1010" {
+		t.Errorf("Check() with a window of 1 found %q truncated, want 1010", got.Truncated)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median([]int64{5, 1, 3}); got != 3 {
+		t.Errorf("median() of an odd count = %v, want 3", got)
+	}
+	if got := median([]int64{4, 1, 3, 10}); got != 3.5 {
+		t.Errorf("median() of an even count = %v, want 3.5", got)
+	}
+}
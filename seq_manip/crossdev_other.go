@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package seq_manip
+
+import (
+	"errors"
+	"syscall"
+)
+
+//Test if a rename failed because source and dest are on different filesystems
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
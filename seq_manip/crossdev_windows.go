@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package seq_manip
+
+import (
+	"errors"
+	"syscall"
+)
+
+//ERROR_NOT_SAME_DEVICE from winerror.h, a rename to another volume
+const error_not_same_device = syscall.Errno(17)
+
+//Test if a rename failed because source and dest are on different volumes
+func isCrossDevice(err error) bool {
+	return errors.Is(err, error_not_same_device)
+}
@@ -0,0 +1,133 @@
+package seq_manip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Create the missing frames of a sequence so that every frame in frames exists.
+//Each missing frame is made from the "prev", "next" or "nearest" online frame,
+//falling back to the other side at the ends of the sequence, using opts.Link.
+//Frames are made like a copy, opts.Workers at a time under opts.Limit, and when
+//one fails the frames already made are removed.  When sidecar is set the
+//placeholder frames are appended to a <name>placeholders.txt file next to the
+//sequence.  Returns the placeholder files that were created
+func FillSeq(fs string, frames []int, from string, sidecar bool, opts Options) ([]string, error) {
+	var created []string
+	fseq, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return created, fs_err
+	}
+	if !strings.Contains(fseq.Base, `@`) {
+		return created, errors.New(fs + " is not a sequence of files, unable to fill")
+	}
+	if valid_err := ValidLinkMode(opts.Link); valid_err != nil {
+		return created, valid_err
+	}
+	if from != "prev" && from != "next" && from != "nearest" {
+		return created, errors.New("Fill from must be prev, next or nearest, not " + from)
+	}
+
+	//Frames of the listing that are online are the ones to fill from
+	var online []int
+	for _, f := range fseq.File_list {
+		isfile, _ := filesys.IsFile(strings.Replace(fseq.Base, `@`, fseq.File_num[f], 1))
+		if isfile {
+			online = append(online, f)
+		}
+	}
+	if len(online) == 0 {
+		return created, errors.New(fseq.F_seq + " has no frames online to fill from")
+	}
+	sort.Ints(online)
+	if len(frames) == 0 {
+		for n := online[0]; n <= online[len(online)-1]; n++ {
+			frames = append(frames, n)
+		}
+	}
+
+	pad := fseq.Padding()
+	fs_source := reducers.File_seq{Base: fseq.Base, File_num: make(map[int]string), F_seq: fseq.F_seq}
+	fs_dest := reducers.File_seq{Base: fseq.Base, File_num: make(map[int]string), F_seq: fseq.F_seq}
+	for _, f := range frames {
+		if isfile, _ := filesys.IsFile(strings.Replace(fseq.Base, `@`, fmt.Sprintf("%0*d", pad, f), 1)); isfile {
+			continue
+		}
+		near := nearestFrame(online, f, from)
+		fs_source.File_num[near] = fseq.File_num[near]
+		fs_source.File_list = append(fs_source.File_list, near)
+		fs_dest.File_num[f] = fmt.Sprintf("%0*d", pad, f)
+		fs_dest.File_list = append(fs_dest.File_list, f)
+	}
+	if len(fs_dest.File_list) == 0 {
+		return created, nil
+	}
+
+	files_source, files_dest, list_err := FormatFileLists(fs_source, fs_dest, false)
+	if list_err != nil {
+		return created, list_err
+	}
+
+	report := newPreserveReport()
+	defer report.print()
+	if ln_err := linkFiles("fill", files_source, files_dest, opts, report); ln_err != nil {
+		return created, ln_err
+	}
+	created = append(created, files_dest...)
+
+	if sidecar {
+		sc_err := writeSidecar(fseq.Base, files_source, files_dest)
+		if sc_err != nil {
+			return created, sc_err
+		}
+	}
+	return created, nil
+}
+
+//Return the online frame to fill a missing frame from
+func nearestFrame(online []int, frame int, from string) int {
+	i := sort.SearchInts(online, frame)
+	if i == 0 {
+		return online[0]
+	}
+	if i == len(online) {
+		return online[len(online)-1]
+	}
+	prev, next := online[i-1], online[i]
+	switch from {
+	case "prev":
+		return prev
+	case "next":
+		return next
+	}
+	if next-frame < frame-prev {
+		return next
+	}
+	return prev
+}
+
+//Append the placeholder frames and the frame they were made from to the
+//sidecar file of a sequence, ie: img.@.exr -> img.placeholders.txt
+func writeSidecar(base string, files_source []string, files_dest []string) error {
+	sidecar := strings.Replace(base, `@`, "placeholders", 1)
+	sidecar = strings.TrimSuffix(sidecar, filepath.Ext(sidecar)) + ".txt"
+	f, open_err := os.OpenFile(sidecar, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if open_err != nil {
+		return open_err
+	}
+	for i, _ := range files_dest {
+		if _, write_err := fmt.Fprintf(f, "%s\t%s\n", files_dest[i], files_source[i]); write_err != nil {
+			f.Close()
+			return write_err
+		}
+	}
+	return f.Close()
+}
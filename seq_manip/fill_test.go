@@ -0,0 +1,100 @@
+package seq_manip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//Write frames of dir/plate.####.exr, each holding its own frame number
+func writePlate(t *testing.T, dir string, frames ...int) {
+	for _, f := range frames {
+		pth := filepath.Join(dir, fmt.Sprintf("plate.%04d.exr", f))
+		if write_err := ioutil.WriteFile(pth, []byte(strconv.Itoa(f)), 0644); write_err != nil {
+			t.Fatal(write_err)
+		}
+	}
+}
+
+//Return what the frames first to last of dir/plate.####.exr hold, separated by
+//spaces, "-" for a frame that does not exist
+func readPlate(dir string, first int, last int) string {
+	var held []string
+	for f := first; f <= last; f++ {
+		data, read_err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("plate.%04d.exr", f)))
+		if read_err != nil {
+			held = append(held, "-")
+			continue
+		}
+		held = append(held, string(data))
+	}
+	return strings.Join(held, " ")
+}
+
+//Missing frames are made from the online frame on the side given by from, a
+//tie between prev and next is the prev frame
+func TestFillSeq(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		frames  []int
+		first   int
+		last    int
+		want    string
+		created int
+	}{
+		{"nearest", "nearest", nil, 1001, 1010, "1001 1002 1002 1005 1005 1005 1005 1010 1010 1010", 6},
+		{"prev", "prev", nil, 1001, 1010, "1001 1002 1002 1002 1005 1005 1005 1005 1005 1010", 6},
+		{"next", "next", nil, 1001, 1010, "1001 1002 1005 1005 1005 1010 1010 1010 1010 1010", 6},
+		{"some frames", "nearest", []int{1003, 1008}, 1001, 1010, "1001 1002 1002 - 1005 - - 1010 - 1010", 2},
+		{"past the ends", "prev", []int{1000, 1012}, 1000, 1012, "1001 1001 1002 - - 1005 - - - - 1010 - 1010", 2},
+		{"online frames are kept", "nearest", []int{1001, 1002}, 1001, 1010, "1001 1002 - - 1005 - - - - 1010", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePlate(t, dir, 1001, 1002, 1005, 1010)
+			created, fill_err := FillSeq(filepath.Join(dir, "plate.[1001-1010].exr"), tt.frames, tt.from, false, Options{Link: "copy", Workers: 4})
+			if fill_err != nil {
+				t.Fatalf("FillSeq() error = %v", fill_err)
+			}
+			if got := readPlate(dir, tt.first, tt.last); got != tt.want {
+				t.Errorf("FillSeq() frames hold %q, want %q", got, tt.want)
+			}
+			if len(created) != tt.created {
+				t.Errorf("FillSeq() created %d frames, want %d", len(created), tt.created)
+			}
+		})
+	}
+}
+
+func TestFillSeqSidecar(t *testing.T) {
+	dir := t.TempDir()
+	writePlate(t, dir, 1001, 1003)
+	if _, fill_err := FillSeq(filepath.Join(dir, "plate.[1001-1003].exr"), nil, "nearest", true, Options{Link: "copy"}); fill_err != nil {
+		t.Fatalf("FillSeq() error = %v", fill_err)
+	}
+	data, read_err := ioutil.ReadFile(filepath.Join(dir, "plate.placeholders.txt"))
+	if read_err != nil {
+		t.Fatal(read_err)
+	}
+	want := filepath.Join(dir, "plate.1002.exr") + "\t" + filepath.Join(dir, "plate.1001.exr") + "\n"
+	if string(data) != want {
+		t.Errorf("FillSeq() sidecar = %q, want %q", data, want)
+	}
+}
+
+func TestFillSeqErrors(t *testing.T) {
+	dir := t.TempDir()
+	writePlate(t, dir, 1001, 1003)
+	listing := filepath.Join(dir, "plate.[1001-1003].exr")
+	if _, fill_err := FillSeq(listing, nil, "middle", false, Options{Link: "copy"}); fill_err == nil {
+		t.Error("FillSeq() from middle did not fail")
+	}
+	if _, fill_err := FillSeq(filepath.Join(dir, "other.[1001-1003].exr"), nil, "nearest", false, Options{Link: "copy"}); fill_err == nil {
+		t.Error("FillSeq() of a sequence with no online frames did not fail")
+	}
+}
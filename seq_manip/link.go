@@ -0,0 +1,118 @@
+package seq_manip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Ways a destination file can be made from a source file:
+//-copy is a full copy validated with an md5 checksum
+//-hard is a hardlink, both names share the same data
+//-sym is a symlink to the absolute path of the source
+//-relative-sym is a symlink to the path of the source relative to the dest
+//-reflink is a copy on write clone, only on filesystems that support it (btrfs, XFS)
+//-auto is a reflink when possible, otherwise a full copy
+var LinkModes = []string{"copy", "hard", "sym", "relative-sym", "reflink", "auto"}
+
+//Test if a link mode is one of the LinkModes
+func ValidLinkMode(mode string) error {
+	for _, m := range LinkModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("Link mode must be one of %s, not %s", strings.Join(LinkModes, ", "), mode)
+}
+
+//Make dest from source using one of the LinkModes, replacing a dest that
+//already exists, see replaceFile.  Callers check for existing files before
+//forcing.  Attributes in opts.Preserve are kept on copies and reflinks, not on
+//links.  Each file waits for its turn under opts.Limit
+func linkFile(source string, dest string, opts Options, report *preserveReport) error {
+	if valid_err := ValidLinkMode(opts.Link); valid_err != nil {
+		return valid_err
+	}
+	opts.Limit.WaitFile()
+	return replaceFile(dest, func() error {
+		return makeFile(source, dest, opts, report)
+	})
+}
+
+//Call write to make dest.  A dest that already exists is moved aside first and
+//only removed once the new file is made, so a failed copy leaves it as it was.
+//Writing into an existing dest could write through a link back into the source
+func replaceFile(dest string, write func() error) error {
+	if _, stat_err := os.Lstat(dest); stat_err != nil {
+		return write()
+	}
+
+	backup := tempName(dest, make(map[string]bool))
+	if mv_err := os.Rename(dest, backup); mv_err != nil {
+		return mv_err
+	}
+	if mk_err := write(); mk_err != nil {
+		os.Remove(dest)
+		os.Rename(backup, dest)
+		return mk_err
+	}
+	return os.Remove(backup)
+}
+
+//Make dest from source using one of the LinkModes, dest must not exist
+func makeFile(source string, dest string, opts Options, report *preserveReport) error {
+	mode := opts.Link
+	verbose := opts.Verbose
+	switch mode {
+	case "copy":
+		return copyFile(source, dest, opts, report)
+	case "auto":
+		if ref_err := reflinkFile(source, dest); ref_err == nil {
+			if verbose {
+				fmt.Printf("%s => %s (reflink)\n", source, dest)
+			}
+			preserveAttrs(source, dest, opts.Preserve, report)
+			return nil
+		}
+		return copyFile(source, dest, opts, report)
+	case "reflink":
+		if verbose {
+			fmt.Printf("%s => %s (reflink)\n", source, dest)
+		}
+		ref_err := reflinkFile(source, dest)
+		if ref_err != nil {
+			return fmt.Errorf("Unable to reflink %s - %v\nThe filesystem may not support reflinks, try -link auto", dest, ref_err)
+		}
+		preserveAttrs(source, dest, opts.Preserve, report)
+		return nil
+	}
+
+	if mode == "hard" {
+		if verbose {
+			fmt.Printf("%s => %s\n", source, dest)
+		}
+		return os.Link(source, dest)
+	}
+
+	target, abs_err := filepath.Abs(source)
+	if abs_err != nil {
+		return abs_err
+	}
+	if mode == "relative-sym" {
+		abs_dest, dest_err := filepath.Abs(dest)
+		if dest_err != nil {
+			return dest_err
+		}
+		rel, rel_err := filepath.Rel(filepath.Dir(abs_dest), target)
+		if rel_err != nil {
+			return errors.New("Unable to make a relative symlink from " + dest + " to " + source)
+		}
+		target = rel
+	}
+	if verbose {
+		fmt.Printf("%s ~> %s\n", target, dest)
+	}
+	return os.Symlink(target, dest)
+}
@@ -0,0 +1,161 @@
+package seq_manip
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, pth string, data string) {
+	if write_err := ioutil.WriteFile(pth, []byte(data), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+}
+
+func TestMakeFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src", "plate.1001.exr")
+	if mk_err := os.Mkdir(filepath.Dir(source), 0755); mk_err != nil {
+		t.Fatal(mk_err)
+	}
+	writeFile(t, source, "1001")
+	abs_source, _ := filepath.Abs(source)
+
+	tests := []struct {
+		mode string
+		//Test the dest made in mode
+		check func(t *testing.T, dest string)
+	}{
+		{"copy", func(t *testing.T, dest string) {
+			if info, _ := os.Lstat(dest); !info.Mode().IsRegular() {
+				t.Errorf("copy made a %v, want a regular file", info.Mode())
+			}
+		}},
+		{"hard", func(t *testing.T, dest string) {
+			src_info, _ := os.Stat(source)
+			dest_info, _ := os.Stat(dest)
+			if !os.SameFile(src_info, dest_info) {
+				t.Error("hard did not make a hardlink to the source")
+			}
+		}},
+		{"sym", func(t *testing.T, dest string) {
+			if target, _ := os.Readlink(dest); target != abs_source {
+				t.Errorf("sym links to %q, want %q", target, abs_source)
+			}
+		}},
+		{"relative-sym", func(t *testing.T, dest string) {
+			want := filepath.Join("..", "src", "plate.1001.exr")
+			if target, _ := os.Readlink(dest); target != want {
+				t.Errorf("relative-sym links to %q, want %q", target, want)
+			}
+		}},
+		{"auto", func(t *testing.T, dest string) {
+			if info, _ := os.Lstat(dest); !info.Mode().IsRegular() {
+				t.Errorf("auto made a %v, want a regular file", info.Mode())
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dest_dir := filepath.Join(dir, tt.mode)
+			if mk_err := os.Mkdir(dest_dir, 0755); mk_err != nil {
+				t.Fatal(mk_err)
+			}
+			dest := filepath.Join(dest_dir, "plate.1001.exr")
+			if mk_err := makeFile(source, dest, Options{Link: tt.mode}, newPreserveReport()); mk_err != nil {
+				t.Fatalf("makeFile() error = %v", mk_err)
+			}
+			if data, read_err := ioutil.ReadFile(dest); read_err != nil || string(data) != "1001" {
+				t.Errorf("%s dest holds %q, %v, want \"1001\"", tt.mode, data, read_err)
+			}
+			tt.check(t, dest)
+		})
+	}
+}
+
+//A reflink either clones the source or fails without leaving a dest behind,
+//depending on the filesystem of the temp dir
+func TestMakeFileReflink(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "plate.1001.exr")
+	dest := filepath.Join(dir, "plate.2001.exr")
+	writeFile(t, source, "1001")
+	if mk_err := makeFile(source, dest, Options{Link: "reflink"}, newPreserveReport()); mk_err != nil {
+		if _, stat_err := os.Lstat(dest); stat_err == nil {
+			t.Errorf("failed reflink left %s behind", dest)
+		}
+		t.Skipf("reflinks are not supported here: %v", mk_err)
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "1001" {
+		t.Errorf("reflink dest holds %q, want \"1001\"", data)
+	}
+}
+
+//A dest that exists is replaced when the new file is made and left as it was
+//when making it fails
+func TestLinkFileExisting(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "plate.1001.exr")
+	dest := filepath.Join(dir, "plate.2001.exr")
+	writeFile(t, source, "1001")
+	writeFile(t, dest, "2001")
+
+	if link_err := linkFile(filepath.Join(dir, "missing.exr"), dest, Options{Link: "copy"}, newPreserveReport()); link_err == nil {
+		t.Error("linkFile() of a missing source did not fail")
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "2001" {
+		t.Errorf("failed linkFile() left dest holding %q, want \"2001\"", data)
+	}
+
+	if link_err := linkFile(source, dest, Options{Link: "copy"}, newPreserveReport()); link_err != nil {
+		t.Fatalf("linkFile() error = %v", link_err)
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "1001" {
+		t.Errorf("linkFile() left dest holding %q, want \"1001\"", data)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("linkFile() left %d files, want 2", len(files))
+	}
+}
+
+func TestValidLinkMode(t *testing.T) {
+	for _, mode := range LinkModes {
+		if valid_err := ValidLinkMode(mode); valid_err != nil {
+			t.Errorf("ValidLinkMode(%q) error = %v", mode, valid_err)
+		}
+	}
+	if valid_err := ValidLinkMode("soft"); valid_err == nil {
+		t.Error("ValidLinkMode(\"soft\") did not fail")
+	}
+}
+
+//A write that fails part way leaves the dest that existed as it was, and one
+//that succeeds leaves no backup behind
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "plate.1001.exr")
+	writeFile(t, dest, "1001")
+
+	rp_err := replaceFile(dest, func() error {
+		writeFile(t, dest, "partial")
+		return errors.New("copy failed")
+	})
+	if rp_err == nil {
+		t.Error("replaceFile() of a failed write did not fail")
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "1001" {
+		t.Errorf("failed replaceFile() left dest holding %q, want \"1001\"", data)
+	}
+
+	if rp_err = replaceFile(dest, func() error { writeFile(t, dest, "2001"); return nil }); rp_err != nil {
+		t.Fatalf("replaceFile() error = %v", rp_err)
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "2001" {
+		t.Errorf("replaceFile() left dest holding %q, want \"2001\"", data)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("replaceFile() left %d files, want 1", len(files))
+	}
+}
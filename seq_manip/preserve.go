@@ -0,0 +1,126 @@
+package seq_manip
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+//Struct for which attributes of a source file are kept on its copy, mirrors
+//cp -p and rsync -a:
+//-Mode is the permission bits, including setuid, setgid and sticky
+//-Times is the access and modification times
+//-Owner is the user and group, only permitted for root or the owner
+//-Xattrs is the extended attributes
+type Preserve struct {
+	Mode   bool
+	Times  bool
+	Owner  bool
+	Xattrs bool
+}
+
+//Preserve every attribute, ie: -preserve all
+var PreserveAll = Preserve{Mode: true, Times: true, Owner: true, Xattrs: true}
+
+//Parse a comma separated list of attributes to preserve ie: "mode,times",
+//valid attributes are mode, times, owner, xattr or all
+func ParsePreserve(list string) (Preserve, error) {
+	var preserve Preserve
+	if list == "" {
+		return preserve, nil
+	}
+	for _, attr := range strings.Split(list, ",") {
+		switch strings.TrimSpace(attr) {
+		case "all":
+			preserve = PreserveAll
+		case "mode":
+			preserve.Mode = true
+		case "times":
+			preserve.Times = true
+		case "owner":
+			preserve.Owner = true
+		case "xattr":
+			preserve.Xattrs = true
+		default:
+			return preserve, fmt.Errorf("%s is not an attribute that can be preserved, use mode, times, owner, xattr or all", attr)
+		}
+	}
+	return preserve, nil
+}
+
+//Struct to collect the attributes that could not be preserved during an
+//operation, so they are reported once instead of for every file.  It may be
+//added to by many workers at once
+type preserveReport struct {
+	mu    sync.Mutex
+	order []string
+	count map[string]int
+	first map[string]string
+}
+
+//Create an empty report
+func newPreserveReport() *preserveReport {
+	return &preserveReport{count: make(map[string]int), first: make(map[string]string)}
+}
+
+//Add an attribute that could not be preserved on a file, a nil report ignores it
+func (r *preserveReport) add(attr string, dest string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count[attr] == 0 {
+		r.order = append(r.order, attr)
+		r.first[attr] = fmt.Sprintf("%s - %v", dest, err)
+	}
+	r.count[attr]++
+}
+
+//Print the attributes that could not be preserved to stdout
+func (r *preserveReport) print() {
+	if r == nil {
+		return
+	}
+	for _, attr := range r.order {
+		fmt.Printf("Unable to preserve %s on %d files, first was %s\n", attr, r.count[attr], r.first[attr])
+	}
+}
+
+//Apply the preserved attributes of source to dest.  Attributes that can not be
+//set are added to the report, they do not fail the copy
+func preserveAttrs(source string, dest string, preserve Preserve, report *preserveReport) {
+	if preserve == (Preserve{}) {
+		return
+	}
+	fi, stat_err := os.Stat(source)
+	if stat_err != nil {
+		report.add("attributes", dest, stat_err)
+		return
+	}
+
+	//Ownership is set first as changing it may clear the setuid and setgid bits
+	if preserve.Owner {
+		if own_err := copyOwner(fi, dest); own_err != nil {
+			report.add("owner", dest, own_err)
+		}
+	}
+	if preserve.Xattrs {
+		if x_err := copyXattrs(source, dest); x_err != nil {
+			report.add("xattr", dest, x_err)
+		}
+	}
+	if preserve.Mode {
+		mode := fi.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		if mode_err := os.Chmod(dest, mode); mode_err != nil {
+			report.add("mode", dest, mode_err)
+		}
+	}
+	//Times are set last so nothing above changes them again
+	if preserve.Times {
+		if time_err := os.Chtimes(dest, fileAtime(fi), fi.ModTime()); time_err != nil {
+			report.add("times", dest, time_err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+//go:build linux
+// +build linux
+
+package seq_manip
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"time"
+)
+
+//Return the access time of a file
+func fileAtime(fi os.FileInfo) time.Time {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fi.ModTime()
+	}
+	return time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec))
+}
+
+//Set the user and group of dest to those of the source
+func copyOwner(fi os.FileInfo, dest string) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return syscall.ENOTSUP
+	}
+	return os.Lchown(dest, int(st.Uid), int(st.Gid))
+}
+
+//Copy every extended attribute of source to dest, the first attribute that can
+//not be copied is returned after trying the rest
+func copyXattrs(source string, dest string) error {
+	names, list_err := listXattrs(source)
+	if list_err != nil {
+		return list_err
+	}
+
+	var first_err error
+	for _, name := range names {
+		value, get_err := getXattr(source, name)
+		if get_err == nil {
+			get_err = syscall.Setxattr(dest, name, value, 0)
+		}
+		if get_err != nil && first_err == nil {
+			first_err = get_err
+		}
+	}
+	return first_err
+}
+
+//Return the names of the extended attributes of a file
+func listXattrs(pth string) ([]string, error) {
+	var names []string
+	size, size_err := syscall.Listxattr(pth, nil)
+	if size_err != nil || size == 0 {
+		if size_err == syscall.ENOTSUP {
+			return names, nil
+		}
+		return names, size_err
+	}
+	buf := make([]byte, size)
+	size, list_err := syscall.Listxattr(pth, buf)
+	if list_err != nil {
+		return names, list_err
+	}
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+//Return the value of an extended attribute of a file
+func getXattr(pth string, name string) ([]byte, error) {
+	size, size_err := syscall.Getxattr(pth, name, nil)
+	if size_err != nil {
+		return nil, size_err
+	}
+	buf := make([]byte, size)
+	size, get_err := syscall.Getxattr(pth, name, buf)
+	if get_err != nil {
+		return nil, get_err
+	}
+	return buf[:size], nil
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+package seq_manip
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+//Access times are not read on this platform, the modification time is used
+func fileAtime(fi os.FileInfo) time.Time {
+	return fi.ModTime()
+}
+
+//Ownership is only preserved on linux
+func copyOwner(fi os.FileInfo, dest string) error {
+	return errors.New("preserving ownership is not supported on this platform")
+}
+
+//Extended attributes are only preserved on linux
+func copyXattrs(source string, dest string) error {
+	return errors.New("preserving extended attributes is not supported on this platform")
+}
@@ -0,0 +1,47 @@
+package seq_manip
+
+import (
+	"os"
+)
+
+//Progress receives updates as an operation works through the files of a
+//sequence, see package progress for the terminal and json displays.
+//-Start is called once with the name of the operation, ie: "copy", and the
+//number of files and bytes it will process
+//-Update is called after each file is done with the file and its size
+//-Finish is called once at the end with the error the operation returns
+type Progress interface {
+	Start(op string, files int, bytes int64)
+	Update(file string, bytes int64)
+	Finish(err error)
+}
+
+//Progress used when an operation has not been given one
+type noProgress struct{}
+
+func (noProgress) Start(op string, files int, bytes int64) {}
+func (noProgress) Update(file string, bytes int64)         {}
+func (noProgress) Finish(err error)                        {}
+
+//Start the progress of an operation over files, returns a Progress that is
+//safe to use when p is nil
+func startProgress(p Progress, op string, files []string) Progress {
+	if p == nil {
+		return noProgress{}
+	}
+	var bytes int64
+	for _, x := range files {
+		bytes += fileSize(x)
+	}
+	p.Start(op, len(files), bytes)
+	return p
+}
+
+//Return the size of a file, or 0 if it can not be read
+func fileSize(pth string) int64 {
+	fi, stat_err := os.Stat(pth)
+	if stat_err != nil {
+		return 0
+	}
+	return fi.Size()
+}
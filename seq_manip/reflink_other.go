@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package seq_manip
+
+import "errors"
+
+//Reflinks are only supported on linux
+func reflinkFile(source string, dest string) error {
+	return errors.New("reflinks are not supported on this platform")
+}
@@ -0,0 +1,110 @@
+package seq_manip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mattbro2/filesequence/undo"
+)
+
+//Order a set of renames so that no file is renamed onto a file that has not
+//been moved out of the way yet.  Renames that form a cycle (ie: swapping two
+//frames) are broken by first renaming one file to a temp name in the same
+//directory.  Renames where source and dest are the same are dropped.
+func PlanRenames(pairs []undo.Pair) ([]undo.Pair, error) {
+	var plan []undo.Pair
+	var todo []undo.Pair
+	by_src := make(map[string]int)
+	dests := make(map[string]bool)
+
+	for _, p := range pairs {
+		if dests[p.Dest] {
+			return plan, errors.New("More than one file would be renamed to " + p.Dest)
+		}
+		dests[p.Dest] = true
+		if p.Source == p.Dest {
+			continue
+		}
+		by_src[p.Source] = len(todo)
+		todo = append(todo, p)
+	}
+
+	//0 is not visited, 1 is waiting on the file in its way, 2 is planned
+	state := make([]int, len(todo))
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = 1
+		if j, ok := by_src[todo[i].Dest]; ok && j != i {
+			if state[j] == 0 {
+				visit(j)
+			} else if state[j] == 1 {
+				temp := tempName(todo[j].Source, dests)
+				plan = append(plan, undo.Pair{Source: todo[j].Source, Dest: temp})
+				todo[j].Source = temp
+			}
+		}
+		plan = append(plan, todo[i])
+		state[i] = 2
+	}
+
+	for i := range todo {
+		if state[i] == 0 {
+			visit(i)
+		}
+	}
+	return plan, nil
+}
+
+//Perform the renames of a plan in order.  Returns the net original -> current
+//location of every file that was moved, so temp names used to break cycles are
+//not returned and a partially completed plan can still be reversed
+func execRenames(plan []undo.Pair, verbose bool) ([]undo.Pair, error) {
+	var order []string
+	origin := make(map[string]string)
+	current := make(map[string]string)
+
+	for _, p := range plan {
+		orig, ok := origin[p.Source]
+		if !ok {
+			orig = p.Source
+			order = append(order, orig)
+		}
+		if verbose {
+			fmt.Printf("%s -> %s\n", p.Source, p.Dest)
+		}
+		mv_err := moveFile(p.Source, p.Dest, Preserve{}, nil, nil)
+		if mv_err != nil {
+			return netRenames(order, current), mv_err
+		}
+		delete(origin, p.Source)
+		origin[p.Dest] = orig
+		current[orig] = p.Dest
+	}
+	return netRenames(order, current), nil
+}
+
+//Return the original -> current pairs of moved files in the order they were
+//first moved
+func netRenames(order []string, current map[string]string) []undo.Pair {
+	var pairs []undo.Pair
+	for _, orig := range order {
+		if current[orig] != "" && current[orig] != orig {
+			pairs = append(pairs, undo.Pair{Source: orig, Dest: current[orig]})
+		}
+	}
+	return pairs
+}
+
+//Return a temp name next to a file that does not exist and is not a planned dest
+func tempName(pth string, dests map[string]bool) string {
+	dir, base := filepath.Split(pth)
+	for n := 0; ; n++ {
+		temp := filepath.Join(dir, fmt.Sprintf(".fseq_tmp%d.%s", n, base))
+		if _, stat_err := os.Lstat(temp); os.IsNotExist(stat_err) && !dests[temp] {
+			dests[temp] = true
+			return temp
+		}
+	}
+}
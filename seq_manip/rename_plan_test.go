@@ -0,0 +1,95 @@
+package seq_manip
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mattbro2/filesequence/undo"
+)
+
+func TestPlanRenames(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		pairs [][2]string
+		temps int
+	}{
+		{"chain", []string{"a", "b"}, [][2]string{{"a", "b"}, {"b", "c"}}, 0},
+		{"swap", []string{"a", "b"}, [][2]string{{"a", "b"}, {"b", "a"}}, 1},
+		{"three cycle", []string{"a", "b", "c"}, [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}}, 1},
+		{"two cycles", []string{"a", "b", "c", "d"}, [][2]string{{"a", "b"}, {"b", "a"}, {"c", "d"}, {"d", "c"}}, 2},
+		{"same name dropped", []string{"a", "b"}, [][2]string{{"a", "a"}, {"b", "c"}}, 0},
+		{"cycle with tail", []string{"a", "b", "c"}, [][2]string{{"a", "b"}, {"b", "a"}, {"c", "a2"}}, 1},
+		{"shift by two", []string{"1", "2", "3", "4", "5"}, [][2]string{{"1", "3"}, {"2", "4"}, {"3", "5"}, {"4", "6"}, {"5", "7"}}, 0},
+		{"reverse", []string{"1", "2", "3", "4"}, [][2]string{{"1", "4"}, {"2", "3"}, {"3", "2"}, {"4", "1"}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			//The files are kept in a map from their path to their original name
+			files := make(map[string]string)
+			for _, x := range tt.files {
+				files[filepath.Join(dir, x)] = x
+			}
+			var pairs []undo.Pair
+			want := make(map[string]string)
+			for _, p := range tt.pairs {
+				pairs = append(pairs, undo.Pair{Source: filepath.Join(dir, p[0]), Dest: filepath.Join(dir, p[1])})
+				want[filepath.Join(dir, p[1])] = p[0]
+			}
+			for pth, orig := range files {
+				if _, renamed := want[pth]; !renamed && !isSource(tt.pairs, orig) {
+					want[pth] = orig
+				}
+			}
+
+			plan, plan_err := PlanRenames(pairs)
+			if plan_err != nil {
+				t.Fatalf("PlanRenames() error = %v", plan_err)
+			}
+			temps := 0
+			for _, p := range plan {
+				orig, ok := files[p.Source]
+				if !ok {
+					t.Fatalf("%s is renamed but does not exist", p.Source)
+				}
+				if _, exists := files[p.Dest]; exists {
+					t.Fatalf("%s is renamed onto %s which still exists", p.Source, p.Dest)
+				}
+				if _, wanted := want[p.Dest]; !wanted {
+					temps++
+				}
+				delete(files, p.Source)
+				files[p.Dest] = orig
+			}
+			if temps != tt.temps {
+				t.Errorf("%d temp names, want %d", temps, tt.temps)
+			}
+			if len(files) != len(want) {
+				t.Errorf("%d files after the plan, want %d", len(files), len(want))
+			}
+			for pth, orig := range want {
+				if files[pth] != orig {
+					t.Errorf("%s is %q, want %q", filepath.Base(pth), files[pth], orig)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRenamesDuplicateDest(t *testing.T) {
+	pairs := []undo.Pair{{Source: "a", Dest: "c"}, {Source: "b", Dest: "c"}}
+	if _, plan_err := PlanRenames(pairs); plan_err == nil {
+		t.Error("PlanRenames() of two files to one dest did not fail")
+	}
+}
+
+//Test if name is renamed by one of pairs
+func isSource(pairs [][2]string, name string) bool {
+	for _, p := range pairs {
+		if p[0] == name {
+			return true
+		}
+	}
+	return false
+}
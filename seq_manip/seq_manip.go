@@ -9,17 +9,44 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"bytes"
 
 	"github.com/mattbro2/filesequence/expanders"
 	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/framemap"
 	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/throttle"
+	"github.com/mattbro2/filesequence/trash"
+	"github.com/mattbro2/filesequence/undo"
 )
 
-//Copy one sequence of files to another, force will allow overwriting.
-//Will perform md5 checksum validation post copy
-func CopySeq(fs string, fd string, force bool, verbose bool) error {
+//Struct for the options of copy, move and fill operations, contains the following:
+//-Force allows overwriting of existing destination files
+//-Link is one of the LinkModes, how copy and fill create destination files
+//-Preserve is which attributes of source files are kept on copies
+//-Progress is sent an update as each file is done, may be nil
+//-Limit is the bytes and files per second of copies and moves, nil is unlimited
+//-Workers is the number of files a copy makes at the same time, 0 is the same as 1
+//-Verbose sends each file operation to stdout
+type Options struct {
+	Force    bool
+	Link     string
+	Preserve Preserve
+	Progress Progress
+	Limit    *throttle.Limiter
+	Workers  int
+	Verbose  bool
+}
+
+//Copy one sequence of files to another, opts.Force will allow overwriting.
+//A full copy will perform md5 checksum validation post copy
+func CopySeq(fs string, fd string, opts Options) error {
+	if valid_err := ValidLinkMode(opts.Link); valid_err != nil {
+		return valid_err
+	}
 	fs_source, fs_err := expanders.Fseq_to_object(fs)
 	if fs_err != nil {
 		return fs_err
@@ -29,7 +56,7 @@ func CopySeq(fs string, fd string, force bool, verbose bool) error {
 		return fd_err
 	}
 
-	files_source, files_dest, fs_err := FormatFileLists(fs_source, fs_dest, force)
+	files_source, files_dest, fs_err := FormatFileLists(fs_source, fs_dest, opts.Force)
 	if fs_err != nil {
 		return fs_err
 	}
@@ -39,86 +66,175 @@ func CopySeq(fs string, fd string, force bool, verbose bool) error {
 		return mk_err
 	}
 
-	for i, _ := range files_source {
-		in, err := os.Open(files_source[i])
-		if err != nil {
-			return err
-		}
-		reader := bufio.NewReader(in)
-		defer in.Close()
-		source_md5, hash_err := hash_file_md5(in)
-		if hash_err != nil {
-			return fmt.Errorf("Unable to generate checksum for source: %v", hash_err)
-		}
+	overlap_err := checkOverlap(files_source, files_dest)
+	if overlap_err != nil {
+		return overlap_err
+	}
 
-		out, err := os.Create(files_dest[i])
-		if err != nil {
-			return err
-		}
-		writer := bufio.NewWriter(out)
-		buf := make([]byte, 1024)
-		defer func() error {
-			if err := out.Close(); err != nil {
-				//DeleteSeq(fd, true, verbose)
-				return fmt.Errorf("Unable to close new file, backing out of copy %v", err)
+	report := newPreserveReport()
+	defer report.print()
+	return linkFiles("copy", files_source, files_dest, opts, report)
+}
+
+//Make each dest from the source at the same index with linkFile, opts.Workers
+//files at a time, op names the operation for progress ie: copy.  When a file
+//fails no more are started, and the files that were created are removed once
+//the files in progress are done.  Files that existed before are not removed,
+//the ones already replaced keep their new copy
+func linkFiles(op string, files_source []string, files_dest []string, opts Options, report *preserveReport) error {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	existed := make([]bool, len(files_dest))
+	for i, x := range files_dest {
+		_, stat_err := os.Lstat(x)
+		existed[i] = stat_err == nil
+	}
+	prog := startProgress(opts.Progress, op, files_source)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var first_err error
+	made := make([]bool, len(files_dest))
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ln_err := linkFile(files_source[i], files_dest[i], opts, report)
+				mu.Lock()
+				if ln_err != nil && first_err == nil {
+					first_err = ln_err
+				}
+				if ln_err == nil {
+					made[i] = true
+					prog.Update(files_dest[i], fileSize(files_dest[i]))
+				}
+				mu.Unlock()
 			}
-			return nil
 		}()
+	}
 
-		if verbose {
-			fmt.Printf("%s -> %s\n", files_source[i], files_dest[i])
+	for i, _ := range files_source {
+		mu.Lock()
+		failed := first_err != nil
+		mu.Unlock()
+		if failed {
+			break
 		}
-
-		for {
-			n, rerr := reader.Read(buf)
-			if rerr != nil && rerr != io.EOF {
-				//DeleteSeq(fd, true, verbose)
-				return fmt.Errorf("Unable to read file %s - %v", files_source[i], rerr)
-			}
-			if n == 0 {
-				break
-			}
-			if _, werr := writer.Write(buf[:n]); werr != nil {
-				//DeleteSeq(fd, true, verbose)
-				return fmt.Errorf("Unable to write file %s - %v", files_dest[i], werr)
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if first_err != nil {
+		var written []string
+		for i, x := range files_dest {
+			if made[i] && !existed[i] {
+				written = append(written, x)
 			}
 		}
+		rollback(written, opts.Verbose)
+	}
+	prog.Finish(first_err)
+	return first_err
+}
 
-		if ferr := writer.Flush(); ferr != nil {
-			//DeleteSeq(fd, true, verbose)
-			return fmt.Errorf("Unable to complete copy of file %s - %v", files_dest[i], ferr)
-		}
+//Copy a single file and validate the copy with an md5 checksum, the dest is
+//removed if the copy can not be completed or is not valid.  Attributes in
+//opts.Preserve are applied to the valid copy
+func copyFile(source string, dest string, opts Options, report *preserveReport) error {
+	cp_err := writeCopy(source, dest, opts.Limit, opts.Verbose)
+	if cp_err != nil {
+		os.Remove(dest)
+		return cp_err
+	}
+	preserveAttrs(source, dest, opts.Preserve, report)
+	return nil
+}
+
+//Write the copy of a file, it is synced to disk before it is validated.  The
+//bytes written are held to the rate of limit
+func writeCopy(source string, dest string, limit *throttle.Limiter, verbose bool) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	source_md5, hash_err := hash_file_md5(in)
+	if hash_err != nil {
+		return fmt.Errorf("Unable to generate checksum for source: %v", hash_err)
+	}
+	if _, seek_err := in.Seek(0, io.SeekStart); seek_err != nil {
+		return fmt.Errorf("Unable to read file %s - %v", source, seek_err)
+	}
+	reader := bufio.NewReader(in)
 
-		//if _, err := io.Copy(out, in); err != nil {
-		//	return err
-		//}
-		//sync_error := out.Sync()
-		//if sync_error != nil {
-		//	return fmt.Errorf("Error syncing file %v", sync_error)
-		//}
-
-		dest_md5, hash_err := hash_file_md5(out)
-		if hash_err != nil {
-			//DeleteSeq(fd, true, verbose)
-			return fmt.Errorf("Unable to generate checksum for source: %v", hash_err)
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	writer := bufio.NewWriter(out)
+	buf := make([]byte, 1024)
+
+	if verbose {
+		fmt.Printf("%s -> %s\n", source, dest)
+	}
+
+	for {
+		n, rerr := reader.Read(buf)
+		if rerr != nil && rerr != io.EOF {
+			return fmt.Errorf("Unable to read file %s - %v", source, rerr)
+		}
+		if n == 0 {
+			break
 		}
+		limit.WaitBytes(n)
+		if _, werr := writer.Write(buf[:n]); werr != nil {
+			return fmt.Errorf("Unable to write file %s - %v", dest, werr)
+		}
+	}
 
-		if !bytes.Equal(source_md5, dest_md5) {
-			if verbose {
-				fmt.Printf("source checksum %v", source_md5)
-				fmt.Printf("dest checksum %v", dest_md5)
-			}
-			//DeleteSeq(fd, true, verbose)
-			return errors.New("Destination files are not valid, backing out of copy.\n" +
-				"Please validate destination to ensure it is writable, ie disk full.\n" +
-				"Or possibly source file is larger than destination FS permits.")
+	if ferr := writer.Flush(); ferr != nil {
+		return fmt.Errorf("Unable to complete copy of file %s - %v", dest, ferr)
+	}
+	if sync_err := out.Sync(); sync_err != nil {
+		return fmt.Errorf("Unable to complete copy of file %s - %v", dest, sync_err)
+	}
+
+	if _, seek_err := out.Seek(0, io.SeekStart); seek_err != nil {
+		return fmt.Errorf("Unable to read back file %s - %v", dest, seek_err)
+	}
+	dest_md5, hash_err := hash_file_md5(out)
+	if hash_err != nil {
+		return fmt.Errorf("Unable to generate checksum for dest: %v", hash_err)
+	}
+
+	if !bytes.Equal(source_md5, dest_md5) {
+		if verbose {
+			fmt.Printf("source checksum %v\n", source_md5)
+			fmt.Printf("dest checksum %v\n", dest_md5)
 		}
+		return errors.New("Destination files are not valid, backing out of copy.\n" +
+			"Please validate destination to ensure it is writable, ie disk full.\n" +
+			"Or possibly source file is larger than destination FS permits.")
+	}
+
+	if close_err := out.Close(); close_err != nil {
+		return fmt.Errorf("Unable to close new file %s - %v", dest, close_err)
 	}
 	return nil
 }
 
-//Rename one sequence to another (not copy).  Original file names will not exist after the move
-func MoveSeq(fs string, fd string, force bool, verbose bool) error {
+//Copy a sequence using a frame mapping expression to retime it, see package
+//framemap.  Destination frames are numbered from the first frame of fd and a
+//source frame may be copied or linked to more than one destination frame
+func CopyMapSeq(fs string, fd string, expr string, opts Options) error {
+	if valid_err := ValidLinkMode(opts.Link); valid_err != nil {
+		return valid_err
+	}
 	fs_source, fs_err := expanders.Fseq_to_object(fs)
 	if fs_err != nil {
 		return fs_err
@@ -128,96 +244,262 @@ func MoveSeq(fs string, fd string, force bool, verbose bool) error {
 		return fd_err
 	}
 
+	files_source, files_dest, map_err := MapFileLists(fs_source, fs_dest, expr, opts.Force)
+	if map_err != nil {
+		return map_err
+	}
+
+	overlap_err := checkOverlap(files_source, files_dest)
+	if overlap_err != nil {
+		return overlap_err
+	}
+
 	mk_err := MakeDir(fd)
 	if mk_err != nil {
 		return mk_err
 	}
 
-	files_source, files_dest, fs_err := FormatFileLists(fs_source, fs_dest, force)
+	report := newPreserveReport()
+	defer report.print()
+	return linkFiles("copy", files_source, files_dest, opts, report)
+}
+
+//Rename one sequence to another (not copy).  Original file names will not exist after the move
+//Frames moved to another filesystem are copied and validated before the source is removed,
+//keeping its mode and times and the attributes of opts.Preserve.  opts.Link is not used
+//Returns the renames that completed so they can be logged for undo
+func MoveSeq(fs string, fd string, opts Options) ([]undo.Pair, error) {
+	var pairs []undo.Pair
+	fs_source, fs_err := expanders.Fseq_to_object(fs)
 	if fs_err != nil {
-		return fs_err
+		return pairs, fs_err
+	}
+	fs_dest, fd_err := expanders.Fseq_to_object(fd)
+	if fd_err != nil {
+		return pairs, fd_err
+	}
+
+	mk_err := MakeDir(fd)
+	if mk_err != nil {
+		return pairs, mk_err
+	}
+
+	files_source, files_dest, fs_err := FormatFileLists(fs_source, fs_dest, opts.Force)
+	if fs_err != nil {
+		return pairs, fs_err
 	}
 
+	overlap_err := checkOverlap(files_source, files_dest)
+	if overlap_err != nil {
+		return pairs, overlap_err
+	}
+
+	report := newPreserveReport()
+	defer report.print()
+	prog := startProgress(opts.Progress, "move", files_source)
 	for i, _ := range files_source {
-		if verbose {
+		if opts.Verbose {
 			fmt.Printf("%s -> %s\n", files_source[i], files_dest[i])
 		}
-		mv_err := os.Rename(files_source[i], files_dest[i])
+		size := fileSize(files_source[i])
+		opts.Limit.WaitFile()
+		mv_err := moveFile(files_source[i], files_dest[i], opts.Preserve, opts.Limit, report)
 		if mv_err != nil {
-			return mv_err
+			prog.Finish(mv_err)
+			return pairs, mv_err
 		}
+		pairs = append(pairs, undo.Pair{Source: files_source[i], Dest: files_dest[i]})
+		prog.Update(files_dest[i], size)
 	}
-	return nil
+	prog.Finish(nil)
+	return pairs, nil
 }
 
-//Renumber a sequence of files, performs a copy to a temp dir, deletes the
-//original files then renames them to the renumbered sequence
-func ReSeq(fs string, fd string, verbose bool) error {
+//Renumber a sequence of files in place.  Files are renamed directly in an
+//order that avoids overwriting frames that have not been moved yet, temp names
+//are only used to break cycles.  No data is copied.
+//Frames of the dest that exist and are not renamed away are only overwritten
+//when opts.Force is set, they are moved to the trash first when use_trash is
+//set, otherwise removed.  opts.Link and opts.Preserve are not used
+//Returns the source -> dest renumbering and the overwritten -> trash renames so
+//they can be logged for undo
+func ReSeq(fs string, fd string, use_trash bool, opts Options) ([]undo.Pair, error) {
+	var pairs []undo.Pair
 	fs_source, fs_err := expanders.Fseq_to_object(fs)
 	if fs_err != nil {
-		return fs_err
+		return pairs, fs_err
 	}
 	fs_dest, fd_err := expanders.Fseq_to_object(fd)
 	if fd_err != nil {
-		return fd_err
+		return pairs, fd_err
 	}
 
 	if fs_source.Base != fs_dest.Base {
-		return errors.New("Source and destination must be the same name and location\n" +
+		return pairs, errors.New("Source and destination must be the same name and location\n" +
 			"This option is only to renumber the files in place.\n" +
 			"You should use copy or move instead.\n")
 	}
 
-	temp_dir := os.TempDir()
-	temp_file := filepath.Base(fd)
-	temp_fd := fmt.Sprintf("%s/%s", temp_dir, temp_file)
-
-	cperr := CopySeq(fs, temp_fd, true, verbose)
-	if cperr != nil {
-		return fmt.Errorf("Unable to create temp files: %v\n", cperr)
+	files_source, files_dest, list_err := FormatFileLists(fs_source, fs_dest, true)
+	if list_err != nil {
+		return pairs, list_err
 	}
 
-	rmerr := DeleteSeq(fs, true, verbose)
-	if rmerr != nil {
-		return fmt.Errorf("Unable to remove original files: %v\n", rmerr)
+	var renames []undo.Pair
+	for i, _ := range files_source {
+		renames = append(renames, undo.Pair{Source: files_source[i], Dest: files_dest[i]})
+	}
+	plan, plan_err := PlanRenames(renames)
+	if plan_err != nil {
+		return pairs, plan_err
 	}
 
-	mverr := MoveSeq(temp_fd, fd, false, verbose)
-	if mverr != nil {
-		return fmt.Errorf("Unable to move renumbered files back to original location\nError was %v\nYou may recover original files here: %s", mverr, temp_fd)
+	overwritten := overwrittenFiles(files_source, files_dest)
+	if len(overwritten) > 0 && !opts.Force {
+		return pairs, errors.New(fs_dest.F_seq + " some destination files already exist and are not part of the renumber\n" +
+			"Use -f to overwrite them, ie: " + overwritten[0] + "\n")
 	}
-	return nil
+	if use_trash {
+		trashed, rm_err := trashFiles(overwritten, opts.Verbose, noProgress{})
+		pairs = append(pairs, trashed...)
+		if rm_err != nil {
+			return pairs, rm_err
+		}
+	} else if rm_err := removeFiles(overwritten, opts.Verbose, noProgress{}); rm_err != nil {
+		return pairs, rm_err
+	}
+
+	renamed, mv_err := execRenames(plan, opts.Verbose)
+	return append(pairs, renamed...), mv_err
+}
 
+//Return the dest files that exist and are not also a source, a renumber
+//would write over them
+func overwrittenFiles(files_source []string, files_dest []string) []string {
+	var overwritten []string
+	sources := make(map[string]bool)
+	for _, x := range files_source {
+		sources[x] = true
+	}
+	for _, x := range files_dest {
+		if _, stat_err := os.Lstat(x); stat_err == nil && !sources[x] {
+			overwritten = append(overwritten, x)
+		}
+	}
+	return overwritten
 }
 
-//Delete the files from disk
-func DeleteSeq(fs string, force bool, verbose bool) error {
+//Delete the files from disk.  When use_trash is set files are moved to the
+//trash so the delete can be undone, otherwise they are removed permanently.
+//opts.Force allows files of the sequence to be offline, opts.Link and
+//opts.Preserve are not used
+//Returns the source -> trash renames so they can be logged for undo
+func DeleteSeq(fs string, use_trash bool, opts Options) ([]undo.Pair, error) {
+	var pairs []undo.Pair
 	fs_source, fs_err := expanders.Fseq_to_object(fs)
 	if fs_err != nil {
-		return fs_err
+		return pairs, fs_err
 	}
 	files_source, files_err := expanders.Fseq_expand(fs_source)
 	if files_err != nil {
-		return files_err
+		return pairs, files_err
 	}
 
-	if !force {
+	if !opts.Force {
 		for _, x := range files_source {
 			isfile, _ := filesys.IsFile(x)
 			if !isfile {
-				return errors.New(fs_source.F_seq + " files to delete are not completely online\n")
+				return pairs, errors.New(fs_source.F_seq + " files to delete are not completely online\n")
 			}
 		}
 	}
 
-	for _, x := range files_source {
+	prog := startProgress(opts.Progress, "delete", files_source)
+	if !use_trash {
+		rm_err := removeFiles(files_source, opts.Verbose, prog)
+		prog.Finish(rm_err)
+		return pairs, rm_err
+	}
+	pairs, rm_err := trashFiles(files_source, opts.Verbose, prog)
+	prog.Finish(rm_err)
+	return pairs, rm_err
+}
+
+//Reverse a logged operation by renaming every destination back to its source.
+//The renames are planned the same way as a renumber so sequences which overlap
+//their original frame numbers can be restored
+func UndoOp(op undo.Operation, verbose bool) error {
+	dests := make(map[string]bool)
+	for _, p := range op.Pairs {
+		dests[p.Dest] = true
+	}
+
+	var reverse []undo.Pair
+	for _, p := range op.Pairs {
+		isfile, _ := filesys.IsFile(p.Dest)
+		if !isfile {
+			return errors.New(p.Dest + " is no longer online, unable to undo " + op.Id + "\n")
+		}
+		isfile, _ = filesys.IsFile(p.Source)
+		if isfile && !dests[p.Source] {
+			return errors.New(p.Source + " already exists, unable to undo " + op.Id + "\n")
+		}
+		mk_err := MakeDir(p.Source)
+		if mk_err != nil {
+			return mk_err
+		}
+		reverse = append(reverse, undo.Pair{Source: p.Dest, Dest: p.Source})
+	}
+
+	plan, plan_err := PlanRenames(reverse)
+	if plan_err != nil {
+		return plan_err
+	}
+	_, mv_err := execRenames(plan, verbose)
+	if mv_err != nil {
+		return mv_err
+	}
+
+	//Files restored from the trash no longer need their .trashinfo, a forced
+	//renumber trashes the frames it overwrote
+	for _, p := range op.Pairs {
+		if op.Kind == "delete" || trash.InTrash(p.Dest) {
+			os.Remove(trash.InfoPath(p.Dest))
+		}
+	}
+	return nil
+}
+
+//Move files to the trash, returns the source -> trash renames that completed
+func trashFiles(files []string, verbose bool, prog Progress) ([]undo.Pair, error) {
+	var pairs []undo.Pair
+	for _, x := range files {
+		if verbose {
+			fmt.Printf("trashing %s\n", x)
+		}
+		size := fileSize(x)
+		item, rm_err := trash.TrashFile(x)
+		if rm_err != nil {
+			return pairs, rm_err
+		}
+		pairs = append(pairs, undo.Pair{Source: x, Dest: item.TrashPath})
+		prog.Update(x, size)
+	}
+	return pairs, nil
+}
+
+//Remove files from disk without moving them to the trash
+func removeFiles(files []string, verbose bool, prog Progress) error {
+	for _, x := range files {
 		if verbose {
 			fmt.Printf("deleting %s\n", x)
 		}
+		size := fileSize(x)
 		rm_err := os.Remove(x)
 		if rm_err != nil {
 			return rm_err
 		}
+		prog.Update(x, size)
 	}
 	return nil
 }
@@ -269,6 +551,93 @@ func FormatFileLists(fs_source reducers.File_seq, fs_dest reducers.File_seq, for
 	return files_source, files_dest, nil
 }
 
+//Take in File_seq objects and a frame mapping expression and return slices of
+//individual files where each source file is copied to the dest file at the same
+//index.  Destination frames are numbered from the first frame of fs_dest with
+//its padding, so only the first frame of the destination listing is used.
+//Source files must be online and destination files must not exist unless forced
+func MapFileLists(fs_source reducers.File_seq, fs_dest reducers.File_seq, expr string, force bool) ([]string, []string, error) {
+	var files_source []string
+	var files_dest []string
+	if !strings.Contains(fs_source.Base, `@`) || !strings.Contains(fs_dest.Base, `@`) {
+		return []string{}, []string{}, errors.New("Frame mapping requires a source and destination sequence")
+	}
+
+	mapped, parse_err := framemap.Parse(expr, fs_source.File_list)
+	if parse_err != nil {
+		return []string{}, []string{}, parse_err
+	}
+
+	first := fs_dest.File_list[0]
+	width := len(fs_dest.File_num[first])
+	for i, f := range mapped {
+		files_source = append(files_source, strings.Replace(fs_source.Base, `@`, fs_source.File_num[f], 1))
+		files_dest = append(files_dest, strings.Replace(fs_dest.Base, `@`, fmt.Sprintf("%0*d", width, first+i), 1))
+	}
+
+	for _, x := range files_source {
+		isfile, _ := filesys.IsFile(x)
+		if !isfile {
+			return []string{}, []string{}, errors.New(fs_source.F_seq + " source is not completely online\n")
+		}
+	}
+	if !force {
+		for _, x := range files_dest {
+			isfile, _ := filesys.IsFile(x)
+			if isfile {
+				return []string{}, []string{}, errors.New(x + " destination file already exists\n")
+			}
+		}
+	}
+	return files_source, files_dest, nil
+}
+
+//Rename a file.  When source and dest are on different filesystems the file is
+//copied instead at the rate of limit, and the source is only removed once the
+//copy has been verified.  A dest that already exists is kept until the copy is
+//verified, see replaceFile.  The copy keeps the mode and times of the source,
+//like a rename, and the attributes of preserve.  Attributes that could not be
+//kept on the copy are added to the report
+func moveFile(source string, dest string, preserve Preserve, limit *throttle.Limiter, report *preserveReport) error {
+	mv_err := os.Rename(source, dest)
+	if mv_err == nil || !isCrossDevice(mv_err) {
+		return mv_err
+	}
+	preserve.Mode = true
+	preserve.Times = true
+	cp_err := replaceFile(dest, func() error {
+		return copyFile(source, dest, Options{Preserve: preserve, Limit: limit}, report)
+	})
+	if cp_err != nil {
+		return fmt.Errorf("Unable to move %s to another filesystem, source was kept - %v", source, cp_err)
+	}
+	return os.Remove(source)
+}
+
+//Remove the files written by a copy that could not be completed
+func rollback(files []string, verbose bool) {
+	for _, x := range files {
+		if verbose {
+			fmt.Printf("backing out %s\n", x)
+		}
+		os.Remove(x)
+	}
+}
+
+//Copy and move cannot write over their own source files, that is a renumber
+func checkOverlap(files_source []string, files_dest []string) error {
+	sources := make(map[string]bool)
+	for _, x := range files_source {
+		sources[x] = true
+	}
+	for _, x := range files_dest {
+		if sources[x] {
+			return errors.New(x + " is both a source and destination file, use renumber (-q) instead")
+		}
+	}
+	return nil
+}
+
 //Create a md5 hash, used for validating copy
 func hash_file_md5(file *os.File) ([]byte, error) {
 	hash := md5.New()
@@ -0,0 +1,83 @@
+package seq_manip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//Return a temp dir on another filesystem than t.TempDir(), or skip the test
+//when there is none.  /dev/shm is a tmpfs on most Linux systems
+func otherFilesystem(t *testing.T) string {
+	dir, mk_err := ioutil.TempDir("/dev/shm", "seq_manip")
+	if mk_err != nil {
+		t.Skipf("no /dev/shm to move files from: %v", mk_err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	probe := filepath.Join(dir, "probe")
+	writeFile(t, probe, "probe")
+	if mv_err := os.Rename(probe, filepath.Join(t.TempDir(), "probe")); !isCrossDevice(mv_err) {
+		t.Skipf("/dev/shm is not another filesystem: %v", mv_err)
+	}
+	return dir
+}
+
+func TestMoveFileAcrossFilesystems(t *testing.T) {
+	src_dir := otherFilesystem(t)
+	dest_dir := t.TempDir()
+	tests := []struct {
+		name   string
+		exists bool
+	}{
+		{"new dest", false},
+		{"existing dest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := filepath.Join(src_dir, "plate.1001.exr")
+			dest := filepath.Join(dest_dir, "plate.1001.exr")
+			writeFile(t, source, "1001")
+			os.Chmod(source, 0600)
+			if tt.exists {
+				writeFile(t, dest, "old")
+			}
+			if mv_err := moveFile(source, dest, Preserve{}, nil, newPreserveReport()); mv_err != nil {
+				t.Fatalf("moveFile() error = %v", mv_err)
+			}
+			if _, stat_err := os.Lstat(source); !os.IsNotExist(stat_err) {
+				t.Errorf("moveFile() kept the source, stat error = %v", stat_err)
+			}
+			if data, _ := ioutil.ReadFile(dest); string(data) != "1001" {
+				t.Errorf("moveFile() dest holds %q, want \"1001\"", data)
+			}
+			if info, _ := os.Stat(dest); info.Mode().Perm() != 0600 {
+				t.Errorf("moveFile() dest mode = %v, want the mode of the source", info.Mode().Perm())
+			}
+			if files, _ := ioutil.ReadDir(dest_dir); len(files) != 1 {
+				t.Errorf("moveFile() left %d files in the dest dir, want 1", len(files))
+			}
+		})
+	}
+}
+
+//A copy that fails keeps both the source and the dest that existed before it
+func TestMoveFileAcrossFilesystemsFails(t *testing.T) {
+	src_dir := otherFilesystem(t)
+	source := filepath.Join(src_dir, "plate.1001.exr")
+	dest := filepath.Join(t.TempDir(), "plate.1001.exr")
+	//A directory can not be copied as a file
+	if mk_err := os.Mkdir(source, 0755); mk_err != nil {
+		t.Fatal(mk_err)
+	}
+	writeFile(t, dest, "old")
+	if mv_err := moveFile(source, dest, Preserve{}, nil, newPreserveReport()); mv_err == nil {
+		t.Fatal("moveFile() of a directory across filesystems did not fail")
+	}
+	if _, stat_err := os.Stat(source); stat_err != nil {
+		t.Errorf("failed moveFile() removed the source: %v", stat_err)
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "old" {
+		t.Errorf("failed moveFile() left dest holding %q, want \"old\"", data)
+	}
+}
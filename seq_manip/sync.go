@@ -0,0 +1,182 @@
+package seq_manip
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/manifest"
+	"github.com/mattbro2/filesequence/reducers"
+	"github.com/mattbro2/filesequence/undo"
+)
+
+//Struct for what a sync did to one sequence, or would do when previewed:
+//-Copied is the dest frames that were missing or different
+//-Current is the number of dest frames that were already the same
+//-Deleted is the dest frames that are not in the source
+type SyncResult struct {
+	Copied  []string
+	Current int
+	Deleted []string
+}
+
+//Make the frames of fd the same as the online frames of fs, frames are
+//matched by their position in the listings.  Only frames that are missing or
+//different at the dest are copied, they are compared by size and modification
+//time, or by checksum when checksum is set.  Copies keep the modification time
+//of the source.  With prune the frames of the dest sequence that are not in the
+//source are deleted, to the trash when use_trash is set.  With preview nothing
+//is changed.  Returns the source -> trash renames so they can be logged for undo
+func SyncSeq(fs string, fd string, checksum bool, prune bool, use_trash bool, preview bool, opts Options) (SyncResult, []undo.Pair, error) {
+	var result SyncResult
+	var pairs []undo.Pair
+	fs_source, fs_err := expanders.Fseq_to_object(fs)
+	if fs_err != nil {
+		return result, pairs, fs_err
+	}
+	fs_dest, fd_err := expanders.Fseq_to_object(fd)
+	if fd_err != nil {
+		return result, pairs, fd_err
+	}
+	if len(fs_source.File_list) != len(fs_dest.File_list) {
+		return result, pairs, errors.New(fs_source.F_seq + " and " + fs_dest.F_seq + " do not contain the same number of files")
+	}
+	if fs_source.Base == fs_dest.Base {
+		return result, pairs, errors.New("Source and destination of a sync must be different")
+	}
+
+	files_source, files_err := expanders.Fseq_expand(fs_source)
+	if files_err != nil {
+		return result, pairs, files_err
+	}
+	files_dest, files_err := expanders.Fseq_expand(fs_dest)
+	if files_err != nil {
+		return result, pairs, files_err
+	}
+
+	var copy_source []string
+	wanted := make(map[string]bool)
+	for i, x := range files_source {
+		if isfile, _ := filesys.IsFile(x); !isfile {
+			continue
+		}
+		wanted[filepath.Clean(files_dest[i])] = true
+		same, cmp_err := syncedFile(x, files_dest[i], checksum)
+		if cmp_err != nil {
+			return result, pairs, cmp_err
+		}
+		if same {
+			result.Current++
+			continue
+		}
+		copy_source = append(copy_source, x)
+		result.Copied = append(result.Copied, files_dest[i])
+	}
+
+	if prune {
+		extra, extra_err := extraFrames(fs_dest.Base, wanted)
+		if extra_err != nil {
+			return result, pairs, extra_err
+		}
+		result.Deleted = extra
+	}
+	if preview {
+		return result, pairs, nil
+	}
+
+	if len(copy_source) > 0 {
+		if mk_err := MakeDir(fd); mk_err != nil {
+			return result, pairs, mk_err
+		}
+		opts.Force = true
+		opts.Preserve.Times = true
+		report := newPreserveReport()
+		defer report.print()
+		if cp_err := linkFiles("copy", copy_source, result.Copied, opts, report); cp_err != nil {
+			return result, pairs, cp_err
+		}
+	}
+
+	if len(result.Deleted) > 0 {
+		prog := startProgress(opts.Progress, "delete", result.Deleted)
+		var rm_err error
+		if use_trash {
+			pairs, rm_err = trashFiles(result.Deleted, opts.Verbose, prog)
+		} else {
+			rm_err = removeFiles(result.Deleted, opts.Verbose, prog)
+		}
+		prog.Finish(rm_err)
+		if rm_err != nil {
+			return result, pairs, rm_err
+		}
+	}
+	return result, pairs, nil
+}
+
+//Test if dest is already a copy of source, by size and modification time to
+//the second, or by size and checksum when checksum is set
+func syncedFile(source string, dest string, checksum bool) (bool, error) {
+	dest_info, dest_err := os.Stat(dest)
+	if os.IsNotExist(dest_err) {
+		return false, nil
+	}
+	if dest_err != nil {
+		return false, dest_err
+	}
+	source_info, source_err := os.Stat(source)
+	if source_err != nil {
+		return false, source_err
+	}
+	if source_info.Size() != dest_info.Size() {
+		return false, nil
+	}
+	if !checksum {
+		return source_info.ModTime().Unix() == dest_info.ModTime().Unix(), nil
+	}
+	source_entry, hash_err := manifest.HashEntry(source, "sha256")
+	if hash_err != nil {
+		return false, hash_err
+	}
+	dest_entry, hash_err := manifest.HashEntry(dest, "sha256")
+	if hash_err != nil {
+		return false, hash_err
+	}
+	return source_entry.Hash == dest_entry.Hash, nil
+}
+
+//Return the frames of a sequence on disk that are not wanted, base is the
+//sequence with its frame number replaced by '@'
+func extraFrames(base string, wanted map[string]bool) ([]string, error) {
+	var extra []string
+	base = filepath.Clean(base)
+	dir_entries, dir_err := ioutil.ReadDir(filepath.Dir(base))
+	if os.IsNotExist(dir_err) {
+		return extra, nil
+	}
+	if dir_err != nil {
+		return extra, dir_err
+	}
+	var files []string
+	for _, x := range dir_entries {
+		if !x.IsDir() {
+			files = append(files, filepath.Join(filepath.Dir(base), x.Name()))
+		}
+	}
+	bases, red_err := reducers.ReduceBase(files)
+	if red_err != nil {
+		return extra, red_err
+	}
+	for _, num := range bases[base] {
+		x := strings.Replace(base, `@`, num, 1)
+		if !wanted[x] {
+			extra = append(extra, x)
+		}
+	}
+	sort.Strings(extra)
+	return extra, nil
+}
@@ -0,0 +1,110 @@
+package seq_manip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//Lay out a synced source and dest, then change the dest: 1002 has different
+//data, 1003 is missing and 1006 is not in the source
+func syncFixture(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{src, dst} {
+		if mk_err := os.Mkdir(d, 0755); mk_err != nil {
+			t.Fatal(mk_err)
+		}
+		writePlate(t, d, 1001, 1002, 1003, 1004, 1005)
+	}
+	mtime := time.Now().Add(-time.Hour)
+	for _, f := range []string{"1001", "1002", "1003", "1004", "1005"} {
+		os.Chtimes(filepath.Join(src, "plate."+f+".exr"), mtime, mtime)
+		os.Chtimes(filepath.Join(dst, "plate."+f+".exr"), mtime, mtime)
+	}
+	writeFile(t, filepath.Join(dst, "plate.1002.exr"), "2002")
+	os.Chtimes(filepath.Join(dst, "plate.1002.exr"), mtime, mtime)
+	os.Remove(filepath.Join(dst, "plate.1003.exr"))
+	writePlate(t, dst, 1006)
+	return src, dst
+}
+
+func TestSyncSeq(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum bool
+		prune    bool
+		preview  bool
+		copied   []string
+		deleted  []string
+		want     string
+	}{
+		{"changed and missing", true, false, false, []string{"1002", "1003"}, nil, "1001 1002 1003 1004 1005 1006"},
+		{"by modification time", false, false, false, []string{"1003"}, nil, "1001 2002 1003 1004 1005 1006"},
+		{"pruned", true, true, false, []string{"1002", "1003"}, []string{"1006"}, "1001 1002 1003 1004 1005 -"},
+		{"preview", true, true, true, []string{"1002", "1003"}, []string{"1006"}, "1001 2002 - 1004 1005 1006"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := syncFixture(t)
+			result, _, sync_err := SyncSeq(filepath.Join(src, "plate.[1001-1005].exr"), filepath.Join(dst, "plate.[1001-1005].exr"), tt.checksum, tt.prune, false, tt.preview, Options{Link: "copy"})
+			if sync_err != nil {
+				t.Fatalf("SyncSeq() error = %v", sync_err)
+			}
+			if got := readPlate(dst, 1001, 1006); got != tt.want {
+				t.Errorf("SyncSeq() dest frames hold %q, want %q", got, tt.want)
+			}
+			var copied, deleted []string
+			for _, f := range tt.copied {
+				copied = append(copied, filepath.Join(dst, "plate."+f+".exr"))
+			}
+			for _, f := range tt.deleted {
+				deleted = append(deleted, filepath.Join(dst, "plate."+f+".exr"))
+			}
+			if !reflect.DeepEqual(result.Copied, copied) {
+				t.Errorf("SyncSeq() copied %v, want %v", result.Copied, copied)
+			}
+			if !reflect.DeepEqual(result.Deleted, deleted) {
+				t.Errorf("SyncSeq() deleted %v, want %v", result.Deleted, deleted)
+			}
+			if result.Current != 5-len(tt.copied) {
+				t.Errorf("SyncSeq() left %d frames current, want %d", result.Current, 5-len(tt.copied))
+			}
+		})
+	}
+}
+
+//A second sync of an unchanged source copies nothing
+func TestSyncSeqUnchanged(t *testing.T) {
+	src, dst := syncFixture(t)
+	fs := filepath.Join(src, "plate.[1001-1005].exr")
+	fd := filepath.Join(dst, "plate.[1001-1005].exr")
+	if _, _, sync_err := SyncSeq(fs, fd, false, false, false, false, Options{Link: "copy"}); sync_err != nil {
+		t.Fatalf("SyncSeq() error = %v", sync_err)
+	}
+	result, _, sync_err := SyncSeq(fs, fd, false, false, false, false, Options{Link: "copy"})
+	if sync_err != nil {
+		t.Fatalf("SyncSeq() error = %v", sync_err)
+	}
+	if len(result.Copied) != 0 || result.Current != 5 {
+		t.Errorf("SyncSeq() of an unchanged source copied %v with %d current, want none with 5", result.Copied, result.Current)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dst, "plate.1002.exr")); string(data) != "2002" {
+		t.Errorf("SyncSeq() by modification time changed plate.1002.exr to %q", data)
+	}
+}
+
+func TestSyncSeqErrors(t *testing.T) {
+	src, dst := syncFixture(t)
+	fs := filepath.Join(src, "plate.[1001-1005].exr")
+	if _, _, sync_err := SyncSeq(fs, filepath.Join(dst, "plate.[1001-1004].exr"), false, false, false, false, Options{Link: "copy"}); sync_err == nil {
+		t.Error("SyncSeq() of listings of different lengths did not fail")
+	}
+	if _, _, sync_err := SyncSeq(fs, fs, false, false, false, false, Options{Link: "copy"}); sync_err == nil {
+		t.Error("SyncSeq() of a sequence to itself did not fail")
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package throttle
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//Adjust the rates while a job is running, SIGUSR1 halves them and SIGUSR2
+//doubles them ie: kill -USR1 <pid>
+func (l *Limiter) WatchSignals() {
+	if l == nil {
+		return
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range sigs {
+			if sig == syscall.SIGUSR1 {
+				l.Scale(0.5)
+			} else {
+				l.Scale(2)
+			}
+		}
+	}()
+}
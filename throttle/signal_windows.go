@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package throttle
+
+//There are no user signals, the rates may only be changed with a control file
+func (l *Limiter) WatchSignals() {
+}
@@ -0,0 +1,242 @@
+//Package throttle limits the bytes and files per second of copies and moves so
+//that large jobs do not saturate shared storage.  One Limiter is shared by all
+//the workers of a job, and its rates may be changed while the job is running
+//from a control file or with signals, see WatchFile and WatchSignals
+package throttle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattbro2/filesequence/config"
+)
+
+//How often a control file is checked for changes
+const ControlInterval = time.Second
+
+//Struct for the rate limits of a job, a rate of 0 is unlimited.  The methods
+//are safe to call from many goroutines and on a nil Limiter, which does not
+//limit anything
+type Limiter struct {
+	mu    sync.Mutex
+	bytes bucket
+	files bucket
+	out   io.Writer
+}
+
+//Token bucket for one rate, it holds at most one second of tokens.  avail goes
+//below 0 when a wait is reserved ahead of the tokens arriving
+type bucket struct {
+	rate  float64
+	avail float64
+	last  time.Time
+}
+
+//Create a Limiter for bytes and files per second, 0 is unlimited.  Changes to
+//the rates are reported to out, which may be nil
+func New(bytes_per_sec float64, files_per_sec float64, out io.Writer) *Limiter {
+	l := &Limiter{out: out}
+	l.SetRates(bytes_per_sec, files_per_sec)
+	return l
+}
+
+//Block until n more bytes may be written
+func (l *Limiter) WaitBytes(n int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	wait := l.bytes.reserve(float64(n), time.Now())
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+//Block until another file may be started
+func (l *Limiter) WaitFile() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	wait := l.files.reserve(1, time.Now())
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+//Return the bytes and files per second, 0 is unlimited
+func (l *Limiter) Rates() (float64, float64) {
+	if l == nil {
+		return 0, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bytes.rate, l.files.rate
+}
+
+//Change the bytes and files per second, 0 is unlimited.  Waits that were
+//already reserved at the old rates are not changed
+func (l *Limiter) SetRates(bytes_per_sec float64, files_per_sec float64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.bytes = bucket{rate: bytes_per_sec, last: now}
+	l.files = bucket{rate: files_per_sec, last: now}
+}
+
+//Multiply both rates by factor, unlimited rates stay unlimited
+func (l *Limiter) Scale(factor float64) {
+	bytes_per_sec, files_per_sec := l.Rates()
+	l.SetRates(bytes_per_sec*factor, files_per_sec*factor)
+	l.report("scaled")
+}
+
+//Check the control file pth every ControlInterval and apply its rates when it
+//changes.  The file uses the config format ie:
+//bytes_per_sec = 50M
+//files_per_sec = 20
+//Keys that are not in the file are unlimited.  When keep is true the rates
+//already set, ie: from the command line, are kept over a control file that
+//exists at the start, until the file changes
+func (l *Limiter) WatchFile(pth string, keep bool) error {
+	if l == nil {
+		return errors.New("No limiter to control")
+	}
+	var last time.Time
+	apply := func() error {
+		fi, stat_err := os.Stat(pth)
+		if stat_err != nil || fi.ModTime().Equal(last) {
+			return stat_err
+		}
+		last = fi.ModTime()
+		bytes_per_sec, files_per_sec, read_err := ReadControl(pth)
+		if read_err != nil {
+			return read_err
+		}
+		if keep {
+			keep = false
+			l.printf("throttle keeping the command line limits until %s changes\n", pth)
+			return nil
+		}
+		l.SetRates(bytes_per_sec, files_per_sec)
+		return nil
+	}
+	//A control file that is missing at the start may be created later
+	if apply_err := apply(); apply_err != nil && !os.IsNotExist(apply_err) {
+		return apply_err
+	}
+	keep = false
+	go func() {
+		for range time.Tick(ControlInterval) {
+			mod := last
+			apply_err := apply()
+			if apply_err != nil && !os.IsNotExist(apply_err) {
+				l.printf("Unable to read %s - %v\n", pth, apply_err)
+				continue
+			}
+			if !last.Equal(mod) {
+				l.report("read " + pth)
+			}
+		}
+	}()
+	return nil
+}
+
+//Read the bytes and files per second of a control file
+func ReadControl(pth string) (float64, float64, error) {
+	values, read_err := config.ReadValues(pth)
+	if read_err != nil {
+		return 0, 0, read_err
+	}
+	var bytes_per_sec, files_per_sec float64
+	for key, value := range values {
+		var parse_err error
+		switch key {
+		case "bytes_per_sec":
+			bytes_per_sec, parse_err = ParseBytes(value)
+		case "files_per_sec":
+			files_per_sec, parse_err = strconv.ParseFloat(value, 64)
+		default:
+			return 0, 0, fmt.Errorf("Unknown setting %s in %s", key, pth)
+		}
+		if parse_err != nil || files_per_sec < 0 {
+			return 0, 0, fmt.Errorf("Setting %s in %s is not a rate", key, pth)
+		}
+	}
+	return bytes_per_sec, files_per_sec, nil
+}
+
+//Parse a number of bytes with an optional unit ie: 500K, 50M, 1.5G or 100MiB.
+//K, M, G and T are powers of 1000, Ki, Mi, Gi and Ti are powers of 1024
+func ParseBytes(s string) (float64, error) {
+	num := strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	num = strings.TrimSuffix(strings.ToUpper(num), "B")
+	mult := 1.0
+	base := 1000.0
+	if strings.HasSuffix(num, "I") {
+		base = 1024
+		num = strings.TrimSuffix(num, "I")
+	}
+	for i, unit := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(num, unit) {
+			num = strings.TrimSuffix(num, unit)
+			for p := 0; p <= i; p++ {
+				mult *= base
+			}
+			break
+		}
+	}
+	n, parse_err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if parse_err != nil || n < 0 {
+		return 0, fmt.Errorf("%s is not a number of bytes ie: 50M", s)
+	}
+	return n * mult, nil
+}
+
+//Format a rate for messages, 0 is unlimited
+func formatRate(rate float64, unit string) string {
+	if rate <= 0 {
+		return "unlimited " + unit
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64) + " " + unit
+}
+
+//Report the current rates to out after they change
+func (l *Limiter) report(why string) {
+	bytes_per_sec, files_per_sec := l.Rates()
+	l.printf("throttle %s, %s, %s\n", why, formatRate(bytes_per_sec, "bytes/s"), formatRate(files_per_sec, "files/s"))
+}
+
+//Print a message to out, if there is one
+func (l *Limiter) printf(format string, a ...interface{}) {
+	if l == nil || l.out == nil {
+		return
+	}
+	fmt.Fprintf(l.out, format, a...)
+}
+
+//Take n tokens from the bucket and return how long to wait for them.  Tokens
+//are added at rate per second up to one second worth, an unlimited bucket
+//never waits
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.avail += now.Sub(b.last).Seconds() * b.rate
+	if b.avail > b.rate {
+		b.avail = b.rate
+	}
+	b.last = now
+	b.avail -= n
+	if b.avail >= 0 {
+		return 0
+	}
+	return time.Duration(-b.avail / b.rate * float64(time.Second))
+}
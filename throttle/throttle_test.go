@@ -0,0 +1,205 @@
+package throttle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	start := time.Now()
+	b := bucket{rate: 100, last: start}
+	steps := []struct {
+		name  string
+		n     float64
+		after time.Duration
+		want  time.Duration
+	}{
+		{"empty at the start", 50, 0, 500 * time.Millisecond},
+		{"waits queue behind each other", 50, 0, time.Second},
+		{"tokens arrive over time", 100, time.Second, time.Second},
+		{"at most one second of tokens", 100, 10 * time.Second, 0},
+		{"within the available tokens", 0, 0, 0},
+	}
+	now := start
+	for _, s := range steps {
+		now = now.Add(s.after)
+		if got := b.reserve(s.n, now); got != s.want {
+			t.Errorf("%s: reserve(%v) = %v, want %v", s.name, s.n, got, s.want)
+		}
+	}
+
+	unlimited := bucket{last: start}
+	if got := unlimited.reserve(1e12, start); got != 0 {
+		t.Errorf("reserve() of an unlimited bucket = %v, want 0", got)
+	}
+}
+
+//Files are started at the files per second rate
+func TestWaitFile(t *testing.T) {
+	l := New(0, 20, nil)
+	begin := time.Now()
+	for i := 0; i < 4; i++ {
+		l.WaitFile()
+	}
+	//The bucket starts empty so 4 files at 20 a second take 200ms
+	if took := time.Since(begin); took < 150*time.Millisecond || took > time.Second {
+		t.Errorf("4 files at 20 files/s took %v, want about 200ms", took)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	l.WaitBytes(1 << 30)
+	l.WaitFile()
+	l.SetRates(10, 10)
+	l.Scale(2)
+	if bytes_per_sec, files_per_sec := l.Rates(); bytes_per_sec != 0 || files_per_sec != 0 {
+		t.Errorf("Rates() of a nil Limiter = %v, %v, want 0, 0", bytes_per_sec, files_per_sec)
+	}
+	if watch_err := l.WatchFile("throttle", false); watch_err == nil {
+		t.Error("WatchFile() of a nil Limiter did not fail")
+	}
+}
+
+func TestScale(t *testing.T) {
+	l := New(1000, 0, nil)
+	l.Scale(0.5)
+	if bytes_per_sec, files_per_sec := l.Rates(); bytes_per_sec != 500 || files_per_sec != 0 {
+		t.Errorf("Rates() after Scale(0.5) = %v, %v, want 500, 0", bytes_per_sec, files_per_sec)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{"500", 500, false},
+		{"500K", 500e3, false},
+		{"50M", 50e6, false},
+		{"50MB/s", 50e6, false},
+		{"1.5G", 1.5e9, false},
+		{"2T", 2e12, false},
+		{"100MiB", 100 * 1024 * 1024, false},
+		{"1ki", 1024, false},
+		{" 10 M ", 10e6, false},
+		{"fast", 0, true},
+		{"-5M", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, parse_err := ParseBytes(tt.s)
+			if (parse_err != nil) != tt.wantErr {
+				t.Fatalf("ParseBytes(%q) error = %v, wantErr %v", tt.s, parse_err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBytes(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		control string
+		bytes   float64
+		files   float64
+		wantErr bool
+	}{
+		{"both", "bytes_per_sec = 50M\nfiles_per_sec = 20\n", 50e6, 20, false},
+		{"missing keys are unlimited", "files_per_sec = 5\n", 0, 5, false},
+		{"empty", "", 0, 0, false},
+		{"unknown key", "speed = 50M\n", 0, 0, true},
+		{"bytes not a rate", "bytes_per_sec = fast\n", 0, 0, true},
+		{"negative files", "files_per_sec = -1\n", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "throttle")
+			if write_err := ioutil.WriteFile(pth, []byte(tt.control), 0644); write_err != nil {
+				t.Fatal(write_err)
+			}
+			bytes_per_sec, files_per_sec, read_err := ReadControl(pth)
+			if (read_err != nil) != tt.wantErr {
+				t.Fatalf("ReadControl() error = %v, wantErr %v", read_err, tt.wantErr)
+			}
+			if bytes_per_sec != tt.bytes || files_per_sec != tt.files {
+				t.Errorf("ReadControl() = %v, %v, want %v, %v", bytes_per_sec, files_per_sec, tt.bytes, tt.files)
+			}
+		})
+	}
+}
+
+//A control file is read at the start and again when it changes, a missing
+//control file may be created later
+func TestWatchFile(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "throttle")
+	l := New(0, 0, nil)
+	if watch_err := l.WatchFile(pth, false); watch_err != nil {
+		t.Fatalf("WatchFile() of a missing file error = %v", watch_err)
+	}
+	if write_err := ioutil.WriteFile(pth, []byte("bytes_per_sec = 1M\n"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	deadline := time.Now().Add(3 * ControlInterval)
+	for {
+		if bytes_per_sec, _ := l.Rates(); bytes_per_sec == 1e6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("WatchFile() did not read the control file once it was created")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	l = New(0, 0, nil)
+	if watch_err := l.WatchFile(pth, false); watch_err != nil {
+		t.Fatalf("WatchFile() error = %v", watch_err)
+	}
+	if bytes_per_sec, _ := l.Rates(); bytes_per_sec != 1e6 {
+		t.Errorf("WatchFile() did not read the control file at the start, bytes/s = %v", bytes_per_sec)
+	}
+}
+
+//With keep the rates already set win over a control file that exists at the
+//start, until the file changes
+func TestWatchFileKeep(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "throttle")
+	if write_err := ioutil.WriteFile(pth, []byte("bytes_per_sec = 1M\n"), 0644); write_err != nil {
+		t.Fatal(write_err)
+	}
+	var out bytes.Buffer
+	l := New(5e6, 0, &out)
+	if watch_err := l.WatchFile(pth, true); watch_err != nil {
+		t.Fatalf("WatchFile() error = %v", watch_err)
+	}
+	if bytes_per_sec, _ := l.Rates(); bytes_per_sec != 5e6 {
+		t.Errorf("WatchFile() replaced the rates at the start, bytes/s = %v", bytes_per_sec)
+	}
+	if !strings.Contains(out.String(), "keeping the command line limits") {
+		t.Errorf("WatchFile() output = %q, want a notice that the limits are kept", out.String())
+	}
+
+	later := time.Now().Add(time.Minute)
+	if touch_err := os.Chtimes(pth, later, later); touch_err != nil {
+		t.Fatal(touch_err)
+	}
+	deadline := time.Now().Add(3 * ControlInterval)
+	for {
+		if bytes_per_sec, _ := l.Rates(); bytes_per_sec == 1e6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("WatchFile() did not read the control file once it changed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
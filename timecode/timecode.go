@@ -0,0 +1,164 @@
+//Package timecode converts between frame numbers and SMPTE timecode ie:
+//01:00:10:00 at a frame rate, so ranges may be given the way editorial gives
+//them.  Drop frame timecode is supported for 29.97 and 59.94, it is written
+//with a ';' before the frames ie: 01:00:10;02
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Struct for a frame rate, contains the following:
+//-Fps is the frames per second ie: 23.976
+//-Base is the frames per second counted by the timecode ie: 24 for 23.976
+//-Drop is true for drop frame timecode
+type Rate struct {
+	Fps  float64
+	Base int
+	Drop bool
+}
+
+//Regex of a timecode, the separators may be ':' or ';'
+var timecode_regex = regexp.MustCompile(`^(\d{1,2})[:;](\d{2})[:;](\d{2})([:;])(\d{2,3})$`)
+
+//Parse a frame rate ie: "24", "23.976", "25", "29.97", "29.97df" or
+//"24000/1001".  A "df" or "drop" suffix is drop frame timecode, which is only
+//defined for 29.97 and 59.94
+func ParseRate(s string) (Rate, error) {
+	num := strings.ToLower(strings.TrimSpace(s))
+	drop := false
+	for _, suffix := range []string{"ndf", "df", "drop", "nd"} {
+		if strings.HasSuffix(num, suffix) {
+			drop = suffix == "df" || suffix == "drop"
+			num = strings.TrimSpace(strings.TrimSuffix(num, suffix))
+			break
+		}
+	}
+	var fps float64
+	var parse_err error
+	if split := strings.SplitN(num, "/", 2); len(split) == 2 {
+		n, n_err := strconv.ParseFloat(split[0], 64)
+		d, d_err := strconv.ParseFloat(split[1], 64)
+		if n_err != nil || d_err != nil || d == 0 {
+			parse_err = errors.New("bad fraction")
+		}
+		fps = n / d
+	} else {
+		fps, parse_err = strconv.ParseFloat(num, 64)
+	}
+	if parse_err != nil || fps <= 0 || fps > 1000 {
+		return Rate{}, fmt.Errorf("%s is not a frame rate ie: 24, 23.976 or 29.97df", s)
+	}
+	r := Rate{Fps: fps, Base: int(math.Round(fps)), Drop: drop}
+	//Rates like 23.976 are kept exactly as 24000/1001
+	if ntsc := float64(r.Base) * 1000 / 1001; fps != float64(r.Base) && math.Abs(fps-ntsc) < 0.005 {
+		r.Fps = ntsc
+	}
+	if drop && (r.Base%30 != 0 || float64(r.Base) == fps) {
+		return Rate{}, fmt.Errorf("Drop frame timecode is only defined for 29.97 and 59.94, not %s", s)
+	}
+	return r, nil
+}
+
+//Format a rate the way it is parsed ie: 29.97df
+func (r Rate) String() string {
+	s := strconv.FormatFloat(math.Round(r.Fps*1000)/1000, 'f', -1, 64)
+	if r.Drop {
+		s += "df"
+	}
+	return s
+}
+
+//Return the frames dropped from the count at the start of each minute, except
+//every tenth minute
+func (r Rate) dropped() int {
+	if !r.Drop {
+		return 0
+	}
+	return r.Base / 15
+}
+
+//Test if s is a timecode ie: 01:00:10:00
+func IsTimecode(s string) bool {
+	return timecode_regex.MatchString(strings.TrimSpace(s))
+}
+
+//Return the number of frames from 00:00:00:00 to a timecode
+func ToFrames(tc string, r Rate) (int, error) {
+	groups := timecode_regex.FindStringSubmatch(strings.TrimSpace(tc))
+	if groups == nil {
+		return 0, fmt.Errorf("%s is not a timecode ie: 01:00:10:00", tc)
+	}
+	hh, _ := strconv.Atoi(groups[1])
+	mm, _ := strconv.Atoi(groups[2])
+	ss, _ := strconv.Atoi(groups[3])
+	ff, _ := strconv.Atoi(groups[5])
+	if mm > 59 || ss > 59 || ff >= r.Base {
+		return 0, fmt.Errorf("%s is not a timecode at %s fps", tc, r)
+	}
+	drop := r.dropped()
+	if drop > 0 && ss == 0 && ff < drop && mm%10 != 0 {
+		return 0, fmt.Errorf("%s is dropped in drop frame timecode", tc)
+	}
+	total_min := hh*60 + mm
+	return (hh*3600+mm*60+ss)*r.Base + ff - drop*(total_min-total_min/10), nil
+}
+
+//Return the timecode of a number of frames from 00:00:00:00
+func FromFrames(n int, r Rate) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	sep := ":"
+	if drop := r.dropped(); drop > 0 {
+		sep = ";"
+		per_min := r.Base*60 - drop
+		per_ten_min := per_min*10 + drop
+		tens, rem := n/per_ten_min, n%per_ten_min
+		n += drop * 9 * tens
+		if rem > drop {
+			n += drop * ((rem - drop) / per_min)
+		}
+	}
+	ff := n % r.Base
+	ss := n / r.Base % 60
+	mm := n / (r.Base * 60) % 60
+	hh := n / (r.Base * 3600)
+	return fmt.Sprintf("%s%02d:%02d:%02d%s%02d", sign, hh, mm, ss, sep, ff)
+}
+
+//Return the frames of a timecode range ie: "01:00:10:00-01:00:12:00" as a
+//frame range ie: "1241-1289".  The range is inclusive like a frame range.
+//start_tc is the timecode of start_frame, the other frames are offset from it.
+//Parts of the range that are already frame numbers are kept as they are
+func FrameRange(tc_range string, r Rate, start_tc string, start_frame int) (string, error) {
+	start, start_err := ToFrames(start_tc, r)
+	if start_err != nil {
+		return "", start_err
+	}
+	var parts []string
+	for _, part := range strings.Split(tc_range, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		if !IsTimecode(bounds[0]) {
+			parts = append(parts, part)
+			continue
+		}
+		var frames []string
+		for _, b := range bounds {
+			n, tc_err := ToFrames(b, r)
+			if tc_err != nil {
+				return "", tc_err
+			}
+			frames = append(frames, strconv.Itoa(n-start+start_frame))
+		}
+		parts = append(parts, strings.Join(frames, "-"))
+	}
+	return strings.Join(parts, ","), nil
+}
@@ -0,0 +1,92 @@
+package timecode
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDropFrame(t *testing.T) {
+	tests := []struct {
+		fps    string
+		tc     string
+		frames int
+	}{
+		{"29.97df", "00:00:00;00", 0},
+		{"29.97df", "00:00:59;29", 1799},
+		{"29.97df", "00:01:00;02", 1800},
+		{"29.97df", "00:02:00;02", 3598},
+		{"29.97df", "00:09:59;29", 17981},
+		{"29.97df", "00:10:00;00", 17982},
+		{"29.97df", "00:10:00;01", 17983},
+		{"29.97df", "00:10:59;29", 19781},
+		{"29.97df", "00:11:00;02", 19782},
+		{"29.97df", "00:19:59;29", 35963},
+		{"29.97df", "00:20:00;00", 35964},
+		{"29.97df", "01:00:00;00", 107892},
+		{"29.97df", "10:00:00;00", 1078920},
+		{"59.94df", "00:00:59;59", 3599},
+		{"59.94df", "00:01:00;04", 3600},
+		{"59.94df", "00:10:00;00", 35964},
+		{"59.94df", "01:00:00;00", 215784},
+		{"29.97", "00:01:00:00", 1800},
+		{"29.97", "01:00:00:00", 108000},
+		{"24", "01:00:10:00", 86640},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fps+" "+tt.tc, func(t *testing.T) {
+			r, rate_err := ParseRate(tt.fps)
+			if rate_err != nil {
+				t.Fatalf("ParseRate(%q) error = %v", tt.fps, rate_err)
+			}
+			frames, tc_err := ToFrames(tt.tc, r)
+			if tc_err != nil {
+				t.Fatalf("ToFrames(%q) error = %v", tt.tc, tc_err)
+			}
+			if frames != tt.frames {
+				t.Errorf("ToFrames(%q) = %d, want %d", tt.tc, frames, tt.frames)
+			}
+			if tc := FromFrames(tt.frames, r); tc != tt.tc {
+				t.Errorf("FromFrames(%d) = %q, want %q", tt.frames, tc, tt.tc)
+			}
+		})
+	}
+}
+
+func TestDroppedTimecodes(t *testing.T) {
+	r, _ := ParseRate("29.97df")
+	for _, tc := range []string{"00:01:00;00", "00:01:00;01", "00:09:00;01", "00:11:00;00"} {
+		if _, tc_err := ToFrames(tc, r); tc_err == nil {
+			t.Errorf("ToFrames(%q) of a dropped timecode did not fail", tc)
+		}
+	}
+	r, _ = ParseRate("59.94df")
+	if _, tc_err := ToFrames("00:01:00;03", r); tc_err == nil {
+		t.Error("ToFrames(\"00:01:00;03\") of a dropped timecode did not fail")
+	}
+}
+
+//Every frame over the first hours converts to a timecode and back, and the
+//timecodes only skip the dropped frames
+func TestDropFrameRoundTrip(t *testing.T) {
+	for _, fps := range []string{"29.97df", "59.94df"} {
+		r, _ := ParseRate(fps)
+		last := -1
+		for n := 0; n < 2*60*60*r.Base; n++ {
+			tc := FromFrames(n, r)
+			frames, tc_err := ToFrames(tc, r)
+			if tc_err != nil || frames != n {
+				t.Fatalf("%s ToFrames(FromFrames(%d)) = %d, %v", fps, n, frames, tc_err)
+			}
+			//The count of a timecode without dropping frames only skips at minutes
+			var hh, mm, ss, ff int
+			if _, scan_err := fmt.Sscanf(tc, "%d:%d:%d;%d", &hh, &mm, &ss, &ff); scan_err != nil {
+				t.Fatalf("%s FromFrames(%d) = %q, %v", fps, n, tc, scan_err)
+			}
+			count := ((hh*60+mm)*60+ss)*r.Base + ff
+			if count != last+1 && !(count == last+1+r.dropped() && ss == 0 && mm%10 != 0) {
+				t.Fatalf("%s FromFrames(%d) = %q skips from the frame before it", fps, n, tc)
+			}
+			last = count
+		}
+	}
+}
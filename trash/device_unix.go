@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package trash
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"syscall"
+)
+
+//Test if two paths are on the same device
+func sameDevice(a string, b string) bool {
+	var st_a, st_b syscall.Stat_t
+	if syscall.Stat(a, &st_a) != nil || syscall.Stat(b, &st_b) != nil {
+		return false
+	}
+	return uint64(st_a.Dev) == uint64(st_b.Dev)
+}
+
+//Return the mount points of mounted volumes, read from /proc/self/mounts
+//where it is available
+func mountPoints() []string {
+	var mounts []string
+	f, open_err := os.Open("/proc/self/mounts")
+	if open_err != nil {
+		return mounts
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		//Spaces in mount points are escaped as octal in /proc/self/mounts
+		mounts = append(mounts, strings.Replace(fields[1], `\040`, " ", -1))
+	}
+	return mounts
+}
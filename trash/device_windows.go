@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package trash
+
+//Device ids are not available, everything is treated as the home volume
+func sameDevice(a string, b string) bool {
+	return true
+}
+
+//Mounted volumes are not listed, only the home trash is used
+func mountPoints() []string {
+	return []string{}
+}
@@ -0,0 +1,310 @@
+//Package trash moves deleted files into a trash directory following the
+//freedesktop.org Trash specification so that they may be restored later.
+//Files on the same volume as the home directory go to $XDG_DATA_HOME/Trash,
+//files on other volumes go to $topdir/.Trash/$uid or $topdir/.Trash-$uid.
+//Every trashed file has a matching .trashinfo file recording where it came from.
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Layout of the DeletionDate key in a .trashinfo file
+const dateLayout = "2006-01-02T15:04:05"
+
+//Struct for a trashed file, contains the following:
+//-Path is the absolute path the file was deleted from
+//-DeletionDate is when the file was trashed
+//-TrashPath is the location of the file inside the trash "files" directory
+//-InfoPath is the location of the matching .trashinfo file
+type Item struct {
+	Path         string
+	DeletionDate time.Time
+	TrashPath    string
+	InfoPath     string
+}
+
+//Move a file into the trash of the volume it lives on and write its .trashinfo
+func TrashFile(pth string) (Item, error) {
+	abs_pth, abs_err := filepath.Abs(pth)
+	if abs_err != nil {
+		return Item{}, abs_err
+	}
+	if _, stat_err := os.Lstat(abs_pth); stat_err != nil {
+		return Item{}, stat_err
+	}
+
+	trash_dir, topdir, dir_err := trashDirFor(abs_pth)
+	if dir_err != nil {
+		return Item{}, dir_err
+	}
+	for _, sub := range []string{"files", "info"} {
+		if mk_err := os.MkdirAll(filepath.Join(trash_dir, sub), 0700); mk_err != nil {
+			return Item{}, mk_err
+		}
+	}
+
+	//Paths in a volume trash are stored relative to the top of the volume
+	info_pth := abs_pth
+	if topdir != "" {
+		rel, rel_err := filepath.Rel(topdir, abs_pth)
+		if rel_err == nil {
+			info_pth = rel
+		}
+	}
+
+	now := time.Now()
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: info_pth}).EscapedPath(), now.Format(dateLayout))
+
+	//The info file is created exclusively first to reserve the name in the trash
+	name := filepath.Base(abs_pth)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		trash_name := name
+		if n > 1 {
+			trash_name = fmt.Sprintf("%s.%d%s", stem, n, ext)
+		}
+		item := Item{
+			Path:         abs_pth,
+			DeletionDate: now,
+			TrashPath:    filepath.Join(trash_dir, "files", trash_name),
+			InfoPath:     filepath.Join(trash_dir, "info", trash_name+".trashinfo"),
+		}
+		if _, stat_err := os.Lstat(item.TrashPath); stat_err == nil {
+			continue
+		}
+		f, create_err := os.OpenFile(item.InfoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(create_err) {
+			continue
+		}
+		if create_err != nil {
+			return Item{}, create_err
+		}
+		_, write_err := f.WriteString(info)
+		close_err := f.Close()
+		if write_err == nil {
+			write_err = close_err
+		}
+		if write_err == nil {
+			write_err = os.Rename(abs_pth, item.TrashPath)
+		}
+		if write_err != nil {
+			os.Remove(item.InfoPath)
+			return Item{}, write_err
+		}
+		return item, nil
+	}
+}
+
+//Return every item in the home trash and the trash directories of mounted
+//volumes, oldest first
+func List() ([]Item, error) {
+	var items []Item
+	for _, dir := range trashDirs() {
+		dir_items, list_err := listDir(dir)
+		if list_err != nil {
+			return items, list_err
+		}
+		items = append(items, dir_items...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletionDate.Before(items[j].DeletionDate)
+	})
+	return items, nil
+}
+
+//Move a trashed file back to where it was deleted from, will not overwrite
+func Restore(item Item) error {
+	if _, stat_err := os.Lstat(item.Path); stat_err == nil {
+		return errors.New(item.Path + " already exists, not restoring from trash")
+	}
+	if mk_err := os.MkdirAll(filepath.Dir(item.Path), 0777); mk_err != nil {
+		return mk_err
+	}
+	if mv_err := os.Rename(item.TrashPath, item.Path); mv_err != nil {
+		return mv_err
+	}
+	return os.Remove(item.InfoPath)
+}
+
+//Permanently remove a trashed file and its .trashinfo
+func Purge(item Item) error {
+	if rm_err := os.RemoveAll(item.TrashPath); rm_err != nil {
+		return rm_err
+	}
+	return os.Remove(item.InfoPath)
+}
+
+//Return the .trashinfo file belonging to a file in a trash "files" directory
+func InfoPath(trash_path string) string {
+	trash_dir := filepath.Dir(filepath.Dir(trash_path))
+	return filepath.Join(trash_dir, "info", filepath.Base(trash_path)+".trashinfo")
+}
+
+//Test if a file is in the "files" directory of one of the trash directories
+func InTrash(pth string) bool {
+	if filepath.Base(filepath.Dir(pth)) != "files" {
+		return false
+	}
+	trash_dir := filepath.Dir(filepath.Dir(pth))
+	for _, dir := range trashDirs() {
+		if dir == trash_dir {
+			return true
+		}
+	}
+	return false
+}
+
+//Return the home trash directory
+func HomeTrash() string {
+	data_home := os.Getenv("XDG_DATA_HOME")
+	if data_home == "" {
+		home, _ := os.UserHomeDir()
+		data_home = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data_home, "Trash")
+}
+
+//Return the trash directory a file should be moved to, and the top directory
+//of the volume when it is not the home trash
+func trashDirFor(pth string) (string, string, error) {
+	home_trash := HomeTrash()
+	if sameDevice(pth, existingParent(home_trash)) {
+		return home_trash, "", nil
+	}
+
+	topdir := mountPoint(pth)
+	uid := strconv.Itoa(os.Getuid())
+
+	//An admin created $topdir/.Trash must be a real directory with the sticky bit
+	shared := filepath.Join(topdir, ".Trash")
+	if fi, stat_err := os.Lstat(shared); stat_err == nil {
+		if fi.IsDir() && fi.Mode()&os.ModeSticky != 0 {
+			return filepath.Join(shared, uid), topdir, nil
+		}
+	}
+	return filepath.Join(topdir, ".Trash-"+uid), topdir, nil
+}
+
+//Return the home trash plus any volume trash directories that exist
+func trashDirs() []string {
+	dirs := []string{HomeTrash()}
+	uid := strconv.Itoa(os.Getuid())
+	for _, mount := range mountPoints() {
+		for _, dir := range []string{filepath.Join(mount, ".Trash", uid), filepath.Join(mount, ".Trash-"+uid)} {
+			if fi, stat_err := os.Stat(filepath.Join(dir, "info")); stat_err == nil && fi.IsDir() && dir != dirs[0] {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+//Read the .trashinfo files of a trash directory
+func listDir(trash_dir string) ([]Item, error) {
+	var items []Item
+	infos, read_err := ioutil.ReadDir(filepath.Join(trash_dir, "info"))
+	if read_err != nil {
+		if os.IsNotExist(read_err) {
+			return items, nil
+		}
+		return items, read_err
+	}
+
+	topdir := ""
+	if trash_dir != HomeTrash() {
+		topdir = filepath.Dir(trash_dir)
+		if filepath.Base(topdir) == ".Trash" {
+			topdir = filepath.Dir(topdir)
+		}
+	}
+
+	for _, fi := range infos {
+		if !strings.HasSuffix(fi.Name(), ".trashinfo") {
+			continue
+		}
+		info_pth := filepath.Join(trash_dir, "info", fi.Name())
+		item, parse_err := parseInfo(info_pth, topdir)
+		if parse_err != nil {
+			continue
+		}
+		item.TrashPath = filepath.Join(trash_dir, "files", strings.TrimSuffix(fi.Name(), ".trashinfo"))
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+//Parse a .trashinfo file, relative paths are joined to the volume top directory
+func parseInfo(info_pth string, topdir string) (Item, error) {
+	item := Item{InfoPath: info_pth}
+	f, open_err := os.Open(info_pth)
+	if open_err != nil {
+		return item, open_err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Path=") {
+			unescaped, esc_err := url.PathUnescape(strings.TrimPrefix(line, "Path="))
+			if esc_err != nil {
+				return item, esc_err
+			}
+			if !filepath.IsAbs(unescaped) {
+				unescaped = filepath.Join(topdir, unescaped)
+			}
+			item.Path = unescaped
+		}
+		if strings.HasPrefix(line, "DeletionDate=") {
+			date, date_err := time.ParseInLocation(dateLayout, strings.TrimPrefix(line, "DeletionDate="), time.Local)
+			if date_err == nil {
+				item.DeletionDate = date
+			}
+		}
+	}
+	if item.Path == "" {
+		return item, errors.New(info_pth + " does not contain a Path")
+	}
+	return item, scanner.Err()
+}
+
+//Return the closest parent of a path that exists, used to find the device
+//of a trash directory before it has been created
+func existingParent(pth string) string {
+	for {
+		if _, stat_err := os.Stat(pth); stat_err == nil {
+			return pth
+		}
+		parent := filepath.Dir(pth)
+		if parent == pth {
+			return pth
+		}
+		pth = parent
+	}
+}
+
+//Walk up from a path until the parent is on another device, this is the
+//top directory of the volume
+func mountPoint(pth string) string {
+	dir := filepath.Dir(pth)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || !sameDevice(dir, parent) {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,142 @@
+//Package undo keeps a log of destructive sequence operations (move, reseq, delete)
+//so that they can be reversed.  Each operation is written as a json file in the
+//undo directory and records every source -> dest rename that completed.
+package undo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Struct for a single file rename, Source is the original file and Dest is
+//where it was moved to
+type Pair struct {
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+}
+
+//Struct for a logged operation, contains the following:
+//-Id is the unique name of the operation, used to undo it
+//-Kind is the operation performed ie: move, reseq, delete
+//-Time the operation was recorded
+//-Pairs is every file rename performed, in the order it was performed
+type Operation struct {
+	Id    string    `json:"id"`
+	Kind  string    `json:"kind"`
+	Time  time.Time `json:"time"`
+	Pairs []Pair    `json:"pairs"`
+}
+
+//Write a new operation to the undo log and return it.  Paths are made
+//absolute so the operation can be reversed from any directory
+func Record(dir string, kind string, pairs []Pair) (Operation, error) {
+	if mk_err := os.MkdirAll(dir, 0700); mk_err != nil {
+		return Operation{}, mk_err
+	}
+
+	var abs_pairs []Pair
+	for _, p := range pairs {
+		source, s_err := filepath.Abs(p.Source)
+		if s_err != nil {
+			return Operation{}, s_err
+		}
+		dest, d_err := filepath.Abs(p.Dest)
+		if d_err != nil {
+			return Operation{}, d_err
+		}
+		abs_pairs = append(abs_pairs, Pair{Source: source, Dest: dest})
+	}
+
+	now := time.Now()
+	op := Operation{
+		Id:    fmt.Sprintf("%s-%s", now.Format("20060102-150405.000000"), kind),
+		Kind:  kind,
+		Time:  now,
+		Pairs: abs_pairs,
+	}
+
+	data, json_err := json.MarshalIndent(op, "", "  ")
+	if json_err != nil {
+		return Operation{}, json_err
+	}
+	write_err := ioutil.WriteFile(opPath(dir, op.Id), data, 0600)
+	if write_err != nil {
+		return Operation{}, write_err
+	}
+	return op, nil
+}
+
+//Return every logged operation, oldest first
+func List(dir string) ([]Operation, error) {
+	var ops []Operation
+	files, read_err := ioutil.ReadDir(dir)
+	if read_err != nil {
+		if os.IsNotExist(read_err) {
+			return ops, nil
+		}
+		return ops, read_err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		op, load_err := readOp(filepath.Join(dir, f.Name()))
+		if load_err != nil {
+			return ops, load_err
+		}
+		ops = append(ops, op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Time.Before(ops[j].Time)
+	})
+	return ops, nil
+}
+
+//Load an operation by id, an id of "last" returns the most recent operation
+func Load(dir string, id string) (Operation, error) {
+	if id != "last" {
+		return readOp(opPath(dir, id))
+	}
+
+	ops, list_err := List(dir)
+	if list_err != nil {
+		return Operation{}, list_err
+	}
+	if len(ops) == 0 {
+		return Operation{}, errors.New("There are no operations in the undo log " + dir)
+	}
+	return ops[len(ops)-1], nil
+}
+
+//Remove an operation from the undo log, used once it has been reversed
+func Remove(dir string, id string) error {
+	return os.Remove(opPath(dir, id))
+}
+
+//Read a single operation file
+func readOp(pth string) (Operation, error) {
+	var op Operation
+	data, read_err := ioutil.ReadFile(pth)
+	if read_err != nil {
+		return op, read_err
+	}
+	json_err := json.Unmarshal(data, &op)
+	if json_err != nil {
+		return op, fmt.Errorf("Unable to read undo log %s - %v", pth, json_err)
+	}
+	return op, nil
+}
+
+//Location of the operation file in the undo directory
+func opPath(dir string, id string) string {
+	return filepath.Join(dir, id+".json")
+}
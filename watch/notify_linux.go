@@ -0,0 +1,156 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/mattbro2/filesequence/filesys"
+)
+
+//Events of a directory that change its files.  Files are added when they are
+//closed after writing or moved in, not when they are created, so a frame is
+//not reported until it is written
+const inotify_mask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+//Send the files added and removed under root using inotify, every directory of
+//the tree is watched and new directories are watched as they are made.  ready
+//is closed once the tree is watched.  Falls back to polling when inotify is not
+//available or opts.ForcePoll is set
+func notify(root string, opts Options, changes chan<- change, ready chan<- struct{}) error {
+	if opts.ForcePoll {
+		close(ready)
+		return poll(root, opts.Poll, changes)
+	}
+	fd, init_err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if init_err != nil {
+		close(ready)
+		return poll(root, opts.Poll, changes)
+	}
+	defer syscall.Close(fd)
+
+	dirs := make(map[int32]string)
+	watchTree := func(dir string, send bool) error {
+		return filepath.Walk(dir, func(pth string, fi os.FileInfo, walk_err error) error {
+			if walk_err != nil {
+				//Directories may be removed while they are walked
+				return nil
+			}
+			if !fi.IsDir() {
+				if send {
+					changes <- change{pth: pth}
+				}
+				return nil
+			}
+			if pth != dir && filesys.IsTrashDir(fi.Name()) {
+				return filepath.SkipDir
+			}
+			wd, add_err := syscall.InotifyAddWatch(fd, pth, inotify_mask)
+			if add_err != nil {
+				return os.NewSyscallError("inotify_add_watch "+pth, add_err)
+			}
+			dirs[int32(wd)] = pth
+			return nil
+		})
+	}
+	//A directory moved away is no longer watched and its files are removed, a
+	//directory moved within the tree is watched again at its new path
+	unwatchTree := func(dir string) {
+		for wd, pth := range dirs {
+			if pth == dir || strings.HasPrefix(pth, dir+string(filepath.Separator)) {
+				syscall.InotifyRmWatch(fd, uint32(wd))
+				delete(dirs, wd)
+			}
+		}
+		changes <- change{pth: dir, removed: true, tree: true}
+	}
+	if watch_err := watchTree(root, false); watch_err != nil {
+		return watch_err
+	}
+	close(ready)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, read_err := syscall.Read(fd, buf)
+		if read_err == syscall.EINTR {
+			continue
+		}
+		if read_err != nil {
+			return os.NewSyscallError("read inotify", read_err)
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name_bytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			//Events were lost, directories made since may not be watched yet
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				if watch_err := watchTree(root, false); watch_err != nil {
+					return watch_err
+				}
+				changes <- change{relist: true}
+				continue
+			}
+			dir, ok := dirs[event.Wd]
+			if !ok {
+				continue
+			}
+			if event.Mask&syscall.IN_DELETE_SELF != 0 {
+				delete(dirs, event.Wd)
+				if dir == root {
+					return os.ErrNotExist
+				}
+				continue
+			}
+			if event.Mask&syscall.IN_MOVE_SELF != 0 {
+				if dir == root {
+					return errors.New(root + " was moved")
+				}
+				unwatchTree(dir)
+				continue
+			}
+			name := string(bytes.TrimRight(name_bytes, "\x00"))
+			pth := filepath.Join(dir, name)
+			is_dir := event.Mask&syscall.IN_ISDIR != 0
+			switch {
+			case is_dir && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				//Files may be written to a new directory before it is watched
+				if watch_err := watchTree(pth, true); watch_err != nil {
+					return watch_err
+				}
+			case is_dir && event.Mask&syscall.IN_MOVED_FROM != 0:
+				unwatchTree(pth)
+			case is_dir:
+				//Other changes to directories are seen through their files
+			case event.Mask&(syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) != 0:
+				changes <- change{pth: pth}
+			case event.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+				changes <- change{pth: pth, removed: true}
+			case event.Mask&syscall.IN_CREATE != 0 && isLink(pth):
+				//Links are not written, so they are added when they are made
+				changes <- change{pth: pth}
+			}
+		}
+	}
+}
+
+//Test if a file is a symlink or a hard link to another file
+func isLink(pth string) bool {
+	fi, stat_err := os.Lstat(pth)
+	if stat_err != nil {
+		return false
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	return ok && st.Nlink > 1
+}
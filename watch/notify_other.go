@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package watch
+
+//inotify is only on Linux, other platforms poll the tree
+func notify(root string, opts Options, changes chan<- change, ready chan<- struct{}) error {
+	close(ready)
+	return poll(root, opts.Poll, changes)
+}
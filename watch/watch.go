@@ -0,0 +1,316 @@
+//Package watch follows the sequences of a directory tree while they are
+//written, ie: during a render.  Changes come from inotify on Linux, or from
+//polling the tree, and are added to the sequences one file at a time with the
+//reducers instead of walking the tree again
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattbro2/filesequence/expanders"
+	"github.com/mattbro2/filesequence/filesys"
+	"github.com/mattbro2/filesequence/reducers"
+)
+
+//Kinds of events
+const (
+	Added    = "added"
+	Deleted  = "deleted"
+	Complete = "complete"
+)
+
+//How long changes are gathered before events are sent, so a render writing
+//many frames at once gives one event
+const Settle = 500 * time.Millisecond
+
+//Struct for a change to a sequence, contains the following:
+//-Event is added, deleted or complete
+//-Sequence is the listing of the sequence after the change, or before it when
+//every frame was deleted
+//-Frames is the frames added or deleted ie: "1041-1050", or the target range
+//of a complete sequence
+type Event struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Sequence string    `json:"sequence"`
+	Frames   string    `json:"frames"`
+}
+
+//Struct for the options of a watch, contains the following:
+//-Target is the frames a sequence must have to be complete, none for no
+//complete events
+//-Poll is how often the tree is checked when polling, 0 is the default of 1
+//second
+//-ForcePoll polls even when inotify is available, ie: for network filesystems
+//that do not report changes made by other hosts
+type Options struct {
+	Target    []int
+	Poll      time.Duration
+	ForcePoll bool
+}
+
+//Struct for a file that was added or removed, or with tree every file under the
+//directory pth that was removed.  With relist changes were lost and the tree is
+//listed again
+type change struct {
+	pth     string
+	removed bool
+	tree    bool
+	relist  bool
+}
+
+//Struct for the sequences of a watched tree, by base as from reducers.ReduceBase
+type watcher struct {
+	opts     Options
+	seqs     map[string]map[int]string
+	complete map[string]bool
+}
+
+//Watch the sequences under root and call emit for each event until an error
+//stops the watch.  The sequences that exist at the start do not send events,
+//except complete for the ones that already have every target frame
+func Watch(root string, opts Options, emit func(Event)) error {
+	if opts.Poll <= 0 {
+		opts.Poll = time.Second
+	}
+	w := &watcher{opts: opts, seqs: make(map[string]map[int]string), complete: make(map[string]bool)}
+	changes := make(chan change, 1024)
+	errs := make(chan error, 1)
+	ready := make(chan struct{})
+	//The tree is listed after every directory is watched so no file is missed
+	//between them
+	go func() { errs <- notify(root, opts, changes, ready) }()
+	select {
+	case <-ready:
+	case notify_err := <-errs:
+		return notify_err
+	}
+	files, rec_err := filesys.Recurse(root, false)
+	if rec_err != nil {
+		return rec_err
+	}
+	var initial []change
+	for _, x := range files {
+		initial = append(initial, change{pth: x})
+	}
+	w.apply(initial)
+	for _, e := range w.completed() {
+		emit(e)
+	}
+
+	var pending []change
+	relist := false
+	settle := time.NewTimer(Settle)
+	settle.Stop()
+	for {
+		select {
+		case c := <-changes:
+			if len(pending) == 0 && !relist {
+				settle.Reset(Settle)
+			}
+			if c.relist {
+				relist = true
+			} else {
+				pending = append(pending, c)
+			}
+		case <-settle.C:
+			if relist {
+				listed, list_err := w.relist(root)
+				if list_err != nil {
+					return list_err
+				}
+				pending = append(pending, listed...)
+				relist = false
+			}
+			for _, e := range w.apply(pending) {
+				emit(e)
+			}
+			for _, e := range w.completed() {
+				emit(e)
+			}
+			pending = nil
+		case notify_err := <-errs:
+			return notify_err
+		}
+	}
+}
+
+//Add and remove the files of changes from the sequences and return the
+//added and deleted events, ordered by sequence
+func (w *watcher) apply(changes []change) []Event {
+	added := make(map[string][]int)
+	deleted := make(map[string][]int)
+	before := make(map[string]string)
+	for _, c := range changes {
+		if c.tree {
+			for base, nums := range w.seqs {
+				if !strings.HasPrefix(base, filepath.Clean(c.pth)+string(filepath.Separator)) {
+					continue
+				}
+				if _, ok := before[base]; !ok {
+					before[base] = w.listing(base)
+				}
+				for n := range nums {
+					delete(nums, n)
+					deleted[base] = append(deleted[base], n)
+				}
+			}
+			continue
+		}
+		if !c.removed {
+			//A file may be removed again before its change is applied
+			if isfile, _ := filesys.IsFile(c.pth); !isfile {
+				continue
+			}
+		}
+		bases, red_err := reducers.ReduceBase([]string{filepath.Clean(c.pth)})
+		if red_err != nil {
+			continue
+		}
+		for base, nums := range bases {
+			//Files that are not part of a sequence are not followed
+			if !strings.Contains(base, `@`) {
+				continue
+			}
+			if _, ok := before[base]; !ok {
+				before[base] = w.listing(base)
+			}
+			for n, num := range nums {
+				_, exists := w.seqs[base][n]
+				if c.removed && exists {
+					delete(w.seqs[base], n)
+					deleted[base] = append(deleted[base], n)
+				} else if !c.removed && !exists {
+					if w.seqs[base] == nil {
+						w.seqs[base] = make(map[int]string)
+					}
+					w.seqs[base][n] = num
+					added[base] = append(added[base], n)
+				}
+			}
+		}
+	}
+
+	var events []Event
+	now := time.Now()
+	for base, frames := range added {
+		events = append(events, Event{Time: now, Event: Added, Sequence: w.listing(base), Frames: expanders.Frame_range_string(frames)})
+	}
+	for base, frames := range deleted {
+		listing := w.listing(base)
+		if len(w.seqs[base]) == 0 {
+			delete(w.seqs, base)
+			listing = before[base]
+		}
+		events = append(events, Event{Time: now, Event: Deleted, Sequence: listing, Frames: expanders.Frame_range_string(frames)})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+	return events
+}
+
+//Return the changes that bring the sequences to the files under root, ie: after
+//the events of the watch overflowed.  Every file is added, the watcher skips the
+//ones it already has, and the frames that are gone are removed
+func (w *watcher) relist(root string) ([]change, error) {
+	files, rec_err := filesys.Recurse(root, false)
+	if rec_err != nil {
+		return nil, rec_err
+	}
+	var listed []change
+	current := make(map[string]bool)
+	for _, x := range files {
+		current[filepath.Clean(x)] = true
+		listed = append(listed, change{pth: x})
+	}
+	for base, nums := range w.seqs {
+		for _, num := range nums {
+			pth := strings.Replace(base, `@`, num, 1)
+			if !current[pth] {
+				listed = append(listed, change{pth: pth, removed: true})
+			}
+		}
+	}
+	return listed, nil
+}
+
+//Return complete events for the sequences that now have every target frame.
+//A sequence that loses a target frame may complete again
+func (w *watcher) completed() []Event {
+	var events []Event
+	if len(w.opts.Target) == 0 {
+		return events
+	}
+	now := time.Now()
+	for base := range w.complete {
+		if _, ok := w.seqs[base]; !ok {
+			delete(w.complete, base)
+		}
+	}
+	for base, nums := range w.seqs {
+		has_all := true
+		for _, f := range w.opts.Target {
+			if _, ok := nums[f]; !ok {
+				has_all = false
+				break
+			}
+		}
+		if has_all && !w.complete[base] {
+			events = append(events, Event{Time: now, Event: Complete, Sequence: w.listing(base), Frames: expanders.Frame_range_string(w.opts.Target)})
+		}
+		w.complete[base] = has_all
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+	return events
+}
+
+//Return the listing of a sequence from its frames, "" when it has none
+func (w *watcher) listing(base string) string {
+	if len(w.seqs[base]) == 0 {
+		return ""
+	}
+	file_seqs, fseq_err := reducers.ReduceFileseq(map[string]map[int]string{base: w.seqs[base]})
+	if fseq_err != nil || len(file_seqs) == 0 {
+		return ""
+	}
+	if len(file_seqs[0].File_list) == 1 {
+		return strings.Replace(base, `@`, "["+file_seqs[0].File_num[file_seqs[0].File_list[0]]+"]", 1)
+	}
+	return file_seqs[0].F_seq
+}
+
+//Check the tree every interval and send the files that were added or removed
+//since the last check.  This is used when inotify is not available
+func poll(root string, interval time.Duration, changes chan<- change) error {
+	//The files of the first check are sent as well, the watcher skips the
+	//ones it already has
+	known := make(map[string]bool)
+	for {
+		files, rec_err := filesys.Recurse(root, false)
+		if rec_err != nil {
+			//Files removed during a check stop it, the next check is used instead
+			if _, stat_err := os.Stat(root); stat_err != nil {
+				return stat_err
+			}
+			time.Sleep(interval)
+			continue
+		}
+		current := make(map[string]bool)
+		for _, x := range files {
+			current[x] = true
+			if !known[x] {
+				changes <- change{pth: x}
+			}
+		}
+		for x := range known {
+			if !current[x] {
+				changes <- change{pth: x, removed: true}
+			}
+		}
+		known = current
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,167 @@
+package watch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//Write frames of dir/plate.####.exr and return their changes
+func writeFrames(t *testing.T, dir string, first int, last int) []change {
+	var changes []change
+	if mk_err := os.MkdirAll(dir, 0755); mk_err != nil {
+		t.Fatal(mk_err)
+	}
+	for f := first; f <= last; f++ {
+		pth := filepath.Join(dir, fmt.Sprintf("plate.%04d.exr", f))
+		if write_err := ioutil.WriteFile(pth, []byte("x"), 0644); write_err != nil {
+			t.Fatal(write_err)
+		}
+		changes = append(changes, change{pth: pth})
+	}
+	return changes
+}
+
+//Return the removals of frames first to last of dir/plate.####.exr
+func removeFrames(t *testing.T, dir string, first int, last int) []change {
+	var changes []change
+	for f := first; f <= last; f++ {
+		pth := filepath.Join(dir, fmt.Sprintf("plate.%04d.exr", f))
+		os.Remove(pth)
+		changes = append(changes, change{pth: pth, removed: true})
+	}
+	return changes
+}
+
+//Return events without their times, as "event sequence frames"
+func summary(events []Event) []string {
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event+" "+e.Sequence+" "+e.Frames)
+	}
+	return got
+}
+
+func checkEvents(t *testing.T, step string, events []Event, want ...string) {
+	got := summary(events)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s: events = %q, want %q", step, got, want)
+	}
+}
+
+func newWatcher(target []int) *watcher {
+	return &watcher{opts: Options{Target: target}, seqs: make(map[string]map[int]string), complete: make(map[string]bool)}
+}
+
+func TestApply(t *testing.T) {
+	root := t.TempDir()
+	sh010 := filepath.Join(root, "sh010")
+	sh020 := filepath.Join(root, "sh020")
+	w := newWatcher(nil)
+
+	checkEvents(t, "add", w.apply(writeFrames(t, sh010, 1001, 1010)),
+		"added "+sh010+"/plate.[1001-1010].exr 1001-1010")
+	checkEvents(t, "add again", w.apply(writeFrames(t, sh010, 1005, 1012)),
+		"added "+sh010+"/plate.[1001-1012].exr 1011-1012")
+
+	changes := append(writeFrames(t, sh020, 1, 2), removeFrames(t, sh010, 1003, 1004)...)
+	checkEvents(t, "add and remove", w.apply(changes),
+		"deleted "+sh010+"/plate.[1001-1002,1005-1012].exr 1003-1004",
+		"added "+sh020+"/plate.[0001-0002].exr 1-2")
+
+	//A file removed before its add is applied is skipped
+	changes = writeFrames(t, sh010, 1013, 1013)
+	os.Remove(changes[0].pth)
+	checkEvents(t, "added then removed", w.apply(changes))
+
+	//Files that are not part of a sequence are not followed
+	notes := filepath.Join(root, "notes.txt")
+	ioutil.WriteFile(notes, []byte("x"), 0644)
+	checkEvents(t, "not a sequence", w.apply([]change{{pth: notes}}))
+
+	//A sequence that loses every frame lists the frames it had
+	os.RemoveAll(sh010)
+	checkEvents(t, "tree removed", w.apply([]change{{pth: sh010, removed: true, tree: true}}),
+		"deleted "+sh010+"/plate.[1001-1002,1005-1012].exr 1001-1002,1005-1012")
+	if _, ok := w.seqs[filepath.Join(sh010, "plate.@.exr")]; ok {
+		t.Error("apply() kept a sequence with no frames")
+	}
+	checkEvents(t, "remove unknown", w.apply(removeFrames(t, sh010, 1001, 1001)))
+}
+
+func TestCompleted(t *testing.T) {
+	dir := t.TempDir()
+	w := newWatcher([]int{1001, 1002, 1003})
+	w.apply(writeFrames(t, dir, 1001, 1002))
+	checkEvents(t, "incomplete", w.completed())
+	w.apply(writeFrames(t, dir, 1003, 1003))
+	checkEvents(t, "complete", w.completed(), "complete "+dir+"/plate.[1001-1003].exr 1001-1003")
+	checkEvents(t, "complete again", w.completed())
+	w.apply(removeFrames(t, dir, 1002, 1002))
+	checkEvents(t, "lost a frame", w.completed())
+	w.apply(writeFrames(t, dir, 1002, 1002))
+	checkEvents(t, "completes again", w.completed(), "complete "+dir+"/plate.[1001-1003].exr 1001-1003")
+}
+
+func TestRelist(t *testing.T) {
+	dir := t.TempDir()
+	w := newWatcher(nil)
+	w.apply(writeFrames(t, dir, 1001, 1005))
+	removeFrames(t, dir, 1001, 1002)
+	writeFrames(t, dir, 1006, 1006)
+	listed, list_err := w.relist(dir)
+	if list_err != nil {
+		t.Fatalf("relist() error = %v", list_err)
+	}
+	checkEvents(t, "relist", w.apply(listed),
+		"added "+dir+"/plate.[1003-1006].exr 1006",
+		"deleted "+dir+"/plate.[1003-1006].exr 1001-1002")
+}
+
+//Watch a tree by polling and check the events of frames written to it
+func TestWatchPoll(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sh010")
+	writeFrames(t, dir, 1001, 1002)
+	events := make(chan Event, 16)
+	errs := make(chan error, 1)
+	opts := Options{Target: []int{1001, 1002, 1003}, Poll: 20 * time.Millisecond, ForcePoll: true}
+	go func() { errs <- Watch(root, opts, func(e Event) { events <- e }) }()
+
+	next := func() string {
+		select {
+		case e := <-events:
+			return summary([]Event{e})[0]
+		case watch_err := <-errs:
+			t.Fatalf("Watch() error = %v", watch_err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Watch() sent no event")
+		}
+		return ""
+	}
+	//The frames that exist at the start do not send events, wait for the first
+	//check to see them before adding more
+	time.Sleep(Settle + 100*time.Millisecond)
+	writeFrames(t, dir, 1003, 1003)
+	if got, want := next(), "added "+dir+"/plate.[1001-1003].exr 1003"; got != want {
+		t.Errorf("Watch() event = %q, want %q", got, want)
+	}
+	if got, want := next(), "complete "+dir+"/plate.[1001-1003].exr 1001-1003"; got != want {
+		t.Errorf("Watch() event = %q, want %q", got, want)
+	}
+	removeFrames(t, dir, 1001, 1001)
+	if got, want := next(), "deleted "+dir+"/plate.[1002-1003].exr 1001"; got != want {
+		t.Errorf("Watch() event = %q, want %q", got, want)
+	}
+
+	//The watch stops when its root is removed
+	os.RemoveAll(root)
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Error("Watch() did not stop when its root was removed")
+	}
+}